@@ -0,0 +1,169 @@
+// Package apperror classifies the errors returned to API clients. Each error has a
+// kind, which decides the HTTP status, and a stable machine-readable code that
+// clients can match on instead of the human-readable message.
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Kinds of error, matched with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnavailable  = errors.New("unavailable")
+
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// StatusClientClosedRequest is the non-standard status, taken from nginx, for
+// requests the client gave up on before the response; it is not a server error
+const StatusClientClosedRequest = 499
+
+// Error is a client-facing error. Message is safe to show to the caller.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Fields lists the individual problems with a request body
+	Fields []FieldError
+}
+
+// FieldError is a problem with one field of a request body. Field is the JSON name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap makes errors.Is match the kind
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func New(kind error, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code string, message string) *Error {
+	return New(ErrNotFound, code, message)
+}
+
+func Forbidden(code string, message string) *Error {
+	return New(ErrForbidden, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+func Validation(code string, message string) *Error {
+	return New(ErrValidation, code, message)
+}
+
+func Unauthorized(code string, message string) *Error {
+	return New(ErrUnauthorized, code, message)
+}
+
+// InvalidFields is a validation error listing every field that failed
+func InvalidFields(code string, message string, fields []FieldError) *Error {
+	err := Validation(code, message)
+	err.Fields = fields
+	return err
+}
+
+// Fields returns the field errors carried by err, if any
+func Fields(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
+
+// Status is the HTTP status for an error of any kind; unclassified errors are 500.
+// A timeout means a dependency such as MongoDB did not answer in time, while a
+// cancellation means the client hung up.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrPreconditionRequired):
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Code is the machine-readable code of an error. Errors without one get a code
+// for their kind.
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	switch Status(err) {
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusBadRequest:
+		return "validation_failed"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusPreconditionRequired:
+		return "precondition_required"
+	case StatusClientClosedRequest:
+		return "client_closed_request"
+	default:
+		return "internal_error"
+	}
+}
+
+// StatusText is http.StatusText, including StatusClientClosedRequest
+func StatusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
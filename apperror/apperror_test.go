@@ -0,0 +1,37 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, Status(NotFound("thing_not_found", "thing not found")))
+	assert.Equal(t, http.StatusForbidden, Status(Forbidden("not_owner", "not yours")))
+	assert.Equal(t, http.StatusConflict, Status(Conflict("duplicate", "already exists")))
+	assert.Equal(t, http.StatusBadRequest, Status(Validation("invalid_body", "bad body")))
+	assert.Equal(t, http.StatusUnauthorized, Status(Unauthorized("invalid_token", "bad token")))
+	assert.Equal(t, http.StatusTooManyRequests, Status(New(ErrRateLimited, "rate_limited", "slow down")))
+	assert.Equal(t, http.StatusPreconditionFailed, Status(New(ErrPreconditionFailed, "version_mismatch", "stale")))
+	assert.Equal(t, http.StatusServiceUnavailable, Status(fmt.Errorf("find: %w", context.DeadlineExceeded)))
+	assert.Equal(t, StatusClientClosedRequest, Status(fmt.Errorf("find: %w", context.Canceled)))
+	assert.Equal(t, http.StatusInternalServerError, Status(errors.New("boom")))
+
+	// Wrapping keeps the kind
+	assert.Equal(t, http.StatusNotFound, Status(fmt.Errorf("load: %w", NotFound("thing_not_found", "thing not found"))))
+}
+
+func TestCode(t *testing.T) {
+	err := fmt.Errorf("load: %w", NotFound("thing_not_found", "thing not found"))
+	assert.Equal(t, "thing_not_found", Code(err))
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	assert.Equal(t, "unavailable", Code(context.DeadlineExceeded))
+	assert.Equal(t, "client_closed_request", Code(context.Canceled))
+	assert.Equal(t, "internal_error", Code(errors.New("boom")))
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores opaque values with a TTL. Entries can be grouped under tags and
+// evicted together, and namespaces carry a version number that callers embed in
+// their keys so a single bump makes every older key unreachable.
+type Cache interface {
+	// Get returns the value and true, or false when the key is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value and records the key under each tag
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, keys ...string) error
+	// InvalidateTags deletes every key recorded under the tags
+	InvalidateTags(ctx context.Context, tags ...string) error
+	Version(ctx context.Context, namespace string) (int64, error)
+	BumpVersion(ctx context.Context, namespace string) (int64, error)
+	// Flush removes every entry
+	Flush(ctx context.Context) error
+	Close() error
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process LRU cache. It is safe for concurrent use.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{}
+	versions   map[string]int64
+	now        func() time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// NewMemory creates an LRU holding at most maxEntries values
+func NewMemory(maxEntries int) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+
+	return &Memory{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+		versions:   make(map[string]int64),
+		now:        time.Now,
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt) {
+		m.removeElement(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.removeElement(element)
+	}
+
+	entry := &memoryEntry{key: key, value: value, tags: tags}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	m.entries[key] = m.order.PushFront(entry)
+
+	for _, tag := range tags {
+		members, ok := m.tags[tag]
+		if !ok {
+			members = make(map[string]struct{})
+			m.tags[tag] = members
+		}
+		members[key] = struct{}{}
+	}
+
+	for m.order.Len() > m.maxEntries {
+		m.removeElement(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.entries[key]; ok {
+			m.removeElement(element)
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if element, ok := m.entries[key]; ok {
+				m.removeElement(element)
+			}
+		}
+		delete(m.tags, tag)
+	}
+
+	return nil
+}
+
+func (m *Memory) Version(ctx context.Context, namespace string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.versions[namespace], nil
+}
+
+func (m *Memory) BumpVersion(ctx context.Context, namespace string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.versions[namespace]++
+	return m.versions[namespace], nil
+}
+
+func (m *Memory) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.order.Init()
+	m.entries = make(map[string]*list.Element)
+	m.tags = make(map[string]map[string]struct{})
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Len returns the number of entries currently held, including expired ones not yet evicted
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+func (m *Memory) removeElement(element *list.Element) {
+	entry := element.Value.(*memoryEntry)
+	m.order.Remove(element)
+	delete(m.entries, entry.key)
+
+	for _, tag := range entry.tags {
+		if members, ok := m.tags[tag]; ok {
+			delete(members, entry.key)
+			if len(members) == 0 {
+				delete(m.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisTagPrefix     = "tag:"
+	redisVersionPrefix = "version:"
+)
+
+// Redis is a Cache backed by a shared Redis database. Tags are Redis sets of
+// keys, so evicting a tag never scans the keyspace.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Client exposes the underlying connection, e.g. for pub/sub
+func (r *Redis) Client() *redis.Client {
+	return r.client
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return r.client.Set(ctx, key, value, ttl).Err()
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, key, value, ttl)
+	for _, tag := range tags {
+		tagKey := redisTagPrefix + tag
+		pipe.SAdd(ctx, tagKey, key)
+		// A tag set lives as long as its longest-lived member
+		pipe.ExpireNX(ctx, tagKey, ttl)
+		pipe.ExpireGT(ctx, tagKey, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func (r *Redis) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		tagKey := redisTagPrefix + tag
+		keys, err := r.client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		// Only the evicted members are removed, so keys tracked meanwhile survive
+		members := make([]interface{}, len(keys))
+		for i, key := range keys {
+			members[i] = key
+		}
+
+		pipe := r.client.TxPipeline()
+		pipe.Unlink(ctx, keys...)
+		pipe.SRem(ctx, tagKey, members...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Redis) Version(ctx context.Context, namespace string) (int64, error) {
+	version, err := r.client.Get(ctx, redisVersionPrefix+namespace).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (r *Redis) BumpVersion(ctx context.Context, namespace string) (int64, error) {
+	return r.client.Incr(ctx, redisVersionPrefix+namespace).Result()
+}
+
+// Flush removes every key in the configured Redis database
+func (r *Redis) Flush(ctx context.Context) error {
+	return r.client.FlushDBAsync(ctx).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
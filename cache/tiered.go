@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// InvalidationChannel is the Redis pub/sub channel replicas use to keep their L1 coherent
+	InvalidationChannel = "cache:invalidate"
+
+	versionKeyPrefix = "__version:"
+	versionL1TTL     = 5 * time.Second
+	healthInterval   = 5 * time.Second
+)
+
+// invalidation is published whenever this replica deletes or evicts entries
+type invalidation struct {
+	Origin     string   `json:"origin"`
+	Keys       []string `json:"keys,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	Flush      bool     `json:"flush,omitempty"`
+}
+
+// Tiered layers a per-replica in-memory L1 over a shared Redis L2. Writes go to
+// both tiers and invalidations are broadcast over pub/sub so other replicas drop
+// their L1 copies. L1 entries live at most l1TTL, which bounds staleness if a
+// broadcast is missed. While Redis is unreachable the cache keeps serving from L1
+// alone and probes Redis in the background until it recovers.
+type Tiered struct {
+	l1     *Memory
+	l2     *Redis
+	l1TTL  time.Duration
+	origin string
+
+	healthy atomic.Bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewTiered creates the two-tier cache and starts its subscriber and health monitor.
+// redisUp reports whether Redis answered at startup.
+func NewTiered(l1 *Memory, l2 *Redis, l1TTL time.Duration, redisUp bool) *Tiered {
+	originBytes := make([]byte, 8)
+	rand.Read(originBytes)
+
+	t := &Tiered{
+		l1:     l1,
+		l2:     l2,
+		l1TTL:  l1TTL,
+		origin: hex.EncodeToString(originBytes),
+		stop:   make(chan struct{}),
+	}
+	t.healthy.Store(redisUp)
+
+	t.wg.Add(2)
+	go t.subscribe()
+	go t.monitor()
+
+	return t
+}
+
+// Healthy reports whether the Redis tier is currently in use
+func (t *Tiered) Healthy() bool {
+	return t.healthy.Load()
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, _ := t.l1.Get(ctx, key); ok {
+		return value, true, nil
+	}
+
+	if !t.Healthy() {
+		return nil, false, nil
+	}
+
+	value, ok, err := t.l2.Get(ctx, key)
+	if err != nil {
+		t.markUnhealthy(err)
+		return nil, false, nil
+	}
+	if ok {
+		t.l1.Set(ctx, key, value, t.l1TTL)
+	}
+
+	return value, ok, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	t.l1.Set(ctx, key, value, t.capL1TTL(ttl), tags...)
+
+	if t.Healthy() {
+		if err := t.l2.Set(ctx, key, value, ttl, tags...); err != nil {
+			t.markUnhealthy(err)
+		}
+	}
+
+	return nil
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	t.l1.Delete(ctx, keys...)
+
+	if t.Healthy() {
+		if err := t.l2.Delete(ctx, keys...); err != nil {
+			t.markUnhealthy(err)
+		}
+	}
+
+	t.publish(ctx, invalidation{Keys: keys})
+	return nil
+}
+
+func (t *Tiered) InvalidateTags(ctx context.Context, tags ...string) error {
+	t.l1.InvalidateTags(ctx, tags...)
+
+	if t.Healthy() {
+		if err := t.l2.InvalidateTags(ctx, tags...); err != nil {
+			t.markUnhealthy(err)
+		}
+	}
+
+	t.publish(ctx, invalidation{Tags: tags})
+	return nil
+}
+
+// Version is read from Redis and memoised in L1 for a few seconds
+func (t *Tiered) Version(ctx context.Context, namespace string) (int64, error) {
+	if !t.Healthy() {
+		return t.l1.Version(ctx, namespace)
+	}
+
+	key := versionKeyPrefix + namespace
+	if value, ok, _ := t.l1.Get(ctx, key); ok {
+		if version, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return version, nil
+		}
+	}
+
+	version, err := t.l2.Version(ctx, namespace)
+	if err != nil {
+		t.markUnhealthy(err)
+		return t.l1.Version(ctx, namespace)
+	}
+
+	t.l1.Set(ctx, key, []byte(strconv.FormatInt(version, 10)), versionL1TTL)
+	return version, nil
+}
+
+func (t *Tiered) BumpVersion(ctx context.Context, namespace string) (int64, error) {
+	t.l1.Delete(ctx, versionKeyPrefix+namespace)
+	localVersion, _ := t.l1.BumpVersion(ctx, namespace)
+
+	if !t.Healthy() {
+		return localVersion, nil
+	}
+
+	version, err := t.l2.BumpVersion(ctx, namespace)
+	if err != nil {
+		t.markUnhealthy(err)
+		return localVersion, nil
+	}
+
+	t.publish(ctx, invalidation{Namespaces: []string{namespace}})
+	return version, nil
+}
+
+func (t *Tiered) Flush(ctx context.Context) error {
+	t.l1.Flush(ctx)
+
+	if err := t.l2.Flush(ctx); err != nil {
+		return err
+	}
+
+	t.publish(ctx, invalidation{Flush: true})
+	return nil
+}
+
+// Close stops the background goroutines and closes the Redis connection
+func (t *Tiered) Close() error {
+	close(t.stop)
+	err := t.l2.Close()
+	t.wg.Wait()
+	return err
+}
+
+func (t *Tiered) capL1TTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > t.l1TTL {
+		return t.l1TTL
+	}
+	return ttl
+}
+
+func (t *Tiered) publish(ctx context.Context, message invalidation) {
+	if !t.Healthy() {
+		return
+	}
+
+	message.Origin = t.origin
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	if err := t.l2.Client().Publish(ctx, InvalidationChannel, payload).Err(); err != nil {
+		t.markUnhealthy(err)
+	}
+}
+
+// apply drops the L1 entries another replica invalidated
+func (t *Tiered) apply(message invalidation) {
+	ctx := context.Background()
+
+	if message.Flush {
+		t.l1.Flush(ctx)
+		return
+	}
+
+	t.l1.Delete(ctx, message.Keys...)
+	t.l1.InvalidateTags(ctx, message.Tags...)
+	for _, namespace := range message.Namespaces {
+		t.l1.Delete(ctx, versionKeyPrefix+namespace)
+	}
+}
+
+func (t *Tiered) subscribe() {
+	defer t.wg.Done()
+
+	pubsub := t.l2.Client().Subscribe(context.Background(), InvalidationChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var message invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				slog.Warn("Ignoring malformed cache invalidation", "error", err)
+				continue
+			}
+			if message.Origin != t.origin {
+				t.apply(message)
+			}
+		}
+	}
+}
+
+func (t *Tiered) monitor() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), healthInterval)
+			err := t.l2.Ping(ctx)
+			cancel()
+
+			if err != nil {
+				t.markUnhealthy(err)
+				continue
+			}
+
+			if !t.healthy.Swap(true) {
+				// Invalidations were missed while Redis was away, so L1 cannot be trusted
+				t.l1.Flush(context.Background())
+				slog.Info("Redis cache is reachable again, two-tier caching resumed")
+			}
+		}
+	}
+}
+
+func (t *Tiered) markUnhealthy(err error) {
+	if t.healthy.Swap(false) {
+		slog.Warn("Redis cache unavailable, serving from in-memory cache only", "error", err)
+	}
+}
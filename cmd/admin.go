@@ -0,0 +1,196 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Seed handles `seed`, filling a development database with sample users and listings
+func Seed(args []string) error {
+	flags, profile := newFlagSet("seed")
+	users := flags.Int("users", 10, "number of users to create")
+	properties := flags.Int("properties", 100, "number of properties to create, spread across the users")
+	password := flags.String("password", "password", "password given to every seeded user")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *users <= 0 || *properties < 0 {
+		return usageError{"--users must be positive and --properties not negative"}
+	}
+
+	app, err := loadApp(*profile)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	ownerIDs := make([]string, 0, *users)
+	for i := 1; i <= *users; i++ {
+		id, err := app.Users.FirstOrCreate(ctx, &models.User{
+			Name:     fmt.Sprintf("Seed User %d", i),
+			Email:    fmt.Sprintf("seed%d@example.com", i),
+			Password: string(hashedPwd),
+			Role:     models.RoleUser,
+		})
+		if err != nil {
+			return fmt.Errorf("creating user %d: %w", i, err)
+		}
+		ownerIDs = append(ownerIDs, id)
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 1; i <= *properties; i++ {
+		property := seedProperty(random, i)
+		property.CreatedBy, _ = primitive.ObjectIDFromHex(ownerIDs[random.Intn(len(ownerIDs))])
+		if _, err := app.Properties.Create(ctx, &property); err != nil {
+			return fmt.Errorf("creating property %d: %w", i, err)
+		}
+	}
+
+	slog.Info("Seeded users and properties; seeded users sign in as seedN@example.com", "users", len(ownerIDs), "properties", *properties)
+	return nil
+}
+
+var (
+	seedCities = []struct{ city, state string }{
+		{"Mumbai", "Maharashtra"}, {"Pune", "Maharashtra"}, {"Bengaluru", "Karnataka"},
+		{"Chennai", "Tamil Nadu"}, {"Coimbatore", "Tamil Nadu"}, {"Hyderabad", "Telangana"},
+	}
+	seedTypes     = []string{"Apartment", "Villa", "Bungalow", "Studio", "Penthouse"}
+	seedAmenities = []string{"lift", "clubhouse", "security", "gym", "garden", "pool", "parking"}
+	seedFurnished = []string{"Furnished", "Semi", "Unfurnished"}
+)
+
+func seedProperty(random *rand.Rand, n int) models.Property {
+	location := seedCities[random.Intn(len(seedCities))]
+	propertyType := seedTypes[random.Intn(len(seedTypes))]
+
+	amenities := make([]string, 0, 3)
+	for _, i := range random.Perm(len(seedAmenities))[:3] {
+		amenities = append(amenities, seedAmenities[i])
+	}
+
+	return models.Property{
+		Title:         fmt.Sprintf("Sample %s %d", propertyType, n),
+		Type:          propertyType,
+		Price:         int64(2_000_000 + random.Intn(48_000_000)),
+		State:         location.state,
+		City:          location.city,
+		AreaSqFt:      int64(500 + random.Intn(4500)),
+		Bedrooms:      1 + random.Intn(5),
+		Bathrooms:     1 + random.Intn(4),
+		Amenities:     amenities,
+		Furnished:     seedFurnished[random.Intn(len(seedFurnished))],
+		AvailableFrom: time.Now().AddDate(0, 0, random.Intn(180)),
+		ListedBy:      "Owner",
+		Rating:        float64(30+random.Intn(21)) / 10,
+		ListingType:   []string{"rent", "sale"}[random.Intn(2)],
+	}
+}
+
+// Cache handles `cache flush`
+func Cache(args []string) error {
+	if len(args) == 0 || args[0] != "flush" {
+		return usageError{"usage: cache flush [--profile name]"}
+	}
+
+	flags, profile := newFlagSet("cache flush")
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	app, err := loadApp(*profile)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	if err := app.Cache.Store().Flush(context.Background()); err != nil {
+		return err
+	}
+
+	slog.Info("Cache flushed")
+	return nil
+}
+
+// User handles `user create`
+func User(args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return usageError{"usage: user create --email address --name name [--role user|admin] [--password secret]"}
+	}
+
+	flags, profile := newFlagSet("user create")
+	email := flags.String("email", "", "email address to sign in with (required)")
+	name := flags.String("name", "", "display name (required)")
+	role := flags.String("role", models.RoleUser, "role of the user: user or admin")
+	password := flags.String("password", "", "password; read from stdin when omitted so it stays out of shell history")
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	if *email == "" || *name == "" {
+		return usageError{"user create requires --email and --name"}
+	}
+	if *role != models.RoleUser && *role != models.RoleAdmin {
+		return usageError{fmt.Sprintf("unknown role %q, expected user or admin", *role)}
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return errors.New("no password given")
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if *password == "" {
+		return usageError{"password must not be empty"}
+	}
+
+	app, err := loadApp(*profile)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	if _, _, err := app.Users.GetCredentials(ctx, *email); err == nil {
+		return fmt.Errorf("a user with email %s already exists", *email)
+	}
+
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	id, err := app.Users.FirstOrCreate(ctx, &models.User{
+		Name:     *name,
+		Email:    *email,
+		Password: string(hashedPwd),
+		Role:     *role,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/handlers/health"
+	"Praiseson6065/Hypergro-assign/logging"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/tracing"
+	"Praiseson6065/Hypergro-assign/views"
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// App holds the connections and repositories shared by the HTTP handlers and
+// background jobs. Handlers only see the repository interfaces, so tests can
+// build an App from in-memory implementations.
+type App struct {
+	Config *config.Config
+
+	Mongo *mongo.Client
+	DB    *mongo.Database
+	Redis *redis.Client
+	Cache *database.CacheLayer
+	Probe *health.Probe
+
+	// RateLimiter counts requests against RateLimits, keyed by route group; a nil
+	// RateLimiter disables rate limiting
+	RateLimiter middleware.RateLimitStore
+	RateLimits  map[string]middleware.RateLimitPolicy
+
+	// shutdownTracing flushes the spans still buffered for export
+	shutdownTracing func(context.Context) error
+
+	Properties      database.PropertyRepository
+	Users           database.UserRepository
+	Favorites       database.FavoriteRepository
+	Recommendations database.RecommendationRepository
+	Notifications   database.NotificationRepository
+
+	// Views records the listings users open; Serve starts and stops it
+	Views *views.Recorder
+}
+
+const (
+	defaultReadinessTimeout = 2 * time.Second
+	tracingFlushTimeout     = 5 * time.Second
+)
+
+// loadApp loads and validates a config profile and builds the App from it
+func loadApp(profile string) (*App, error) {
+	appConfig, err := config.Load(profile)
+	if err != nil {
+		return nil, err
+	}
+	return NewApp(appConfig)
+}
+
+// NewApp connects to MongoDB and Redis and builds the Mongo repositories. An
+// unreachable Redis is not fatal; the cache degrades to memory.
+func NewApp(appConfig *config.Config) (*App, error) {
+	configureLogging(appConfig.Logging.Level)
+	middleware.ConfigureJWT(appConfig.JWT.Secret, time.Duration(appConfig.JWT.Expire)*time.Minute)
+	cfg := database.NewDBConfig(appConfig)
+
+	// Tracing comes first so the MongoDB and Redis clients pick up the tracer provider
+	shutdownTracing, err := tracing.Setup(context.Background(), appConfig.Tracing, appConfig.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoClient, err := database.ConnectMongo(cfg)
+	if err != nil {
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	var redisClient *redis.Client
+	redisUp := false
+	if cfg.CacheMode != database.CacheModeMemory {
+		redisClient, err = database.ConnectRedis(cfg)
+		if err != nil {
+			slog.Warn("Redis connection failed, continuing with the in-memory cache", "error", err)
+		} else {
+			redisUp = true
+		}
+	}
+
+	cache := database.NewCacheLayer(database.NewCache(cfg, redisClient, redisUp), cfg.ServeStale)
+	db := mongoClient.Database(cfg.MongoDBName)
+
+	readinessTimeout := time.Duration(appConfig.Server.ReadinessTimeout) * time.Second
+	if readinessTimeout <= 0 {
+		readinessTimeout = defaultReadinessTimeout
+	}
+	probe := health.NewProbe(readinessTimeout)
+	probe.Register("mongo", func(ctx context.Context) error {
+		return mongoClient.Ping(ctx, readpref.Primary())
+	})
+	if redisClient != nil {
+		probe.Register("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+
+	var rateLimiter middleware.RateLimitStore
+	if appConfig.RateLimit.Enabled {
+		if redisClient != nil {
+			rateLimiter = middleware.NewRedisRateLimitStore(redisClient)
+		} else {
+			rateLimiter = middleware.NewMemoryRateLimitStore()
+		}
+	}
+
+	users := database.NewMongoUserRepository(db, cache, cfg.Timeouts)
+
+	return &App{
+		Config: appConfig,
+
+		Mongo: mongoClient,
+		DB:    db,
+		Redis: redisClient,
+		Cache: cache,
+		Probe: probe,
+
+		RateLimiter: rateLimiter,
+		RateLimits:  rateLimitPolicies(appConfig.RateLimit),
+
+		shutdownTracing: shutdownTracing,
+
+		Properties:      database.NewMongoPropertyRepository(db, cache, cfg.Timeouts),
+		Users:           users,
+		Favorites:       database.NewMongoFavoriteRepository(db, cache, cfg.Timeouts),
+		Recommendations: database.NewMongoRecommendationRepository(db, cache, cfg.Timeouts),
+		Notifications:   database.NewMongoNotificationRepository(db, cfg.Timeouts),
+
+		Views: views.NewRecorder(users, appConfig.Views.QueueSize, appConfig.Views.Workers),
+	}, nil
+}
+
+// rateLimitPolicies converts the configured policies, keyed by route group
+func rateLimitPolicies(cfg config.RateLimitConfig) map[string]middleware.RateLimitPolicy {
+	policies := make(map[string]middleware.RateLimitPolicy)
+	for name, policy := range cfg.Policies() {
+		policies[name] = middleware.RateLimitPolicy{
+			Name:     name,
+			Limit:    policy.Limit,
+			Window:   time.Duration(policy.Window) * time.Second,
+			Identity: policy.Identity,
+		}
+	}
+	return policies
+}
+
+// rateLimit limits a route group by its configured policy
+func (a *App) rateLimit(group string) gin.HandlerFunc {
+	return middleware.RateLimit(a.RateLimiter, a.RateLimits[group])
+}
+
+// configureLogging installs the JSON logger at the level named by logging.level
+func configureLogging(name string) {
+	if name == "" {
+		logging.Setup(os.Stderr, slog.LevelInfo)
+		return
+	}
+
+	level, err := logging.ParseLevel(name)
+	logging.Setup(os.Stderr, level)
+	if err != nil {
+		slog.Warn("Unknown logging.level, logging at info", "level", name)
+	}
+}
+
+// Close releases the cache and database connections and flushes pending spans
+func (a *App) Close() {
+	if a.Cache != nil {
+		if err := a.Cache.Store().Close(); err != nil {
+			slog.Error("Error closing cache", "error", err)
+		}
+		slog.Info("Closed cache")
+	}
+
+	database.DisconnectMongo(a.Mongo)
+
+	if a.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := a.shutdownTracing(ctx); err != nil {
+			slog.Error("Error flushing traces", "error", err)
+		}
+	}
+}
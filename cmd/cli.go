@@ -0,0 +1,122 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Exit codes returned by the binary
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is one entry of the CLI. run receives the arguments after the command name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// usageError marks a mistake in how the command was invoked rather than a runtime failure
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+func commands() []command {
+	return []command{
+		{name: "serve", summary: "Start the HTTP server (default)", run: Serve},
+		{name: "migrate", summary: "Apply, roll back or list schema migrations: migrate [up|down|status]", run: Migrate},
+		{name: "import", summary: "Bulk import properties from a CSV file", run: Import},
+		{name: "seed", summary: "Create sample users and properties", run: Seed},
+		{name: "cache", summary: "Manage the cache: cache flush", run: Cache},
+		{name: "user", summary: "Manage users: user create", run: User},
+		{name: "health", summary: "Probe a running instance and exit non-zero when unhealthy", run: Health},
+	}
+}
+
+// Execute runs the command named by args and returns the process exit code
+func Execute(args []string) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	// Older images probe with `/main --health`
+	if args[0] == "--health" || args[0] == "-health" {
+		args = append([]string{"health"}, args[1:]...)
+	}
+
+	// Commands that load a profile reconfigure logging for it in NewApp
+	configureLogging("")
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return exitCode(cmd.run(args[1:]))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		if usage.message != "" {
+			fmt.Fprintln(os.Stderr, usage.message)
+		}
+		return exitUsage
+	default:
+		slog.Error("Command failed", "error", err)
+		return exitFailure
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: main <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run `main <command> -h` for the flags of a command.")
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting,
+// with the --profile flag shared by every command
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	profile := flags.String("profile", config.DefaultProfile(), "config profile to use, e.g. development or production")
+	return flags, profile
+}
+
+// parseFlags parses args, turning flag mistakes into a usage error. The flag package
+// has already printed the problem and the defaults by then.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{}
+	}
+	return err
+}
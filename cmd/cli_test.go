@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExecuteExitCodes(t *testing.T) {
+	assert.Equal(t, exitOK, Execute([]string{"help"}))
+	assert.Equal(t, exitUsage, Execute([]string{"bogus"}))
+	assert.Equal(t, exitUsage, Execute([]string{"import"}))
+	assert.Equal(t, exitUsage, Execute([]string{"import", "--file", "x.csv", "--owner", "not-an-id"}))
+	assert.Equal(t, exitUsage, Execute([]string{"migrate", "sideways"}))
+	assert.Equal(t, exitUsage, Execute([]string{"serve", "--no-such-flag"}))
+	assert.Equal(t, exitUsage, Execute([]string{"cache"}))
+	assert.Equal(t, exitUsage, Execute([]string{"user", "create", "--email", "a@example.com", "--name", "A", "--role", "root"}))
+	assert.Equal(t, exitOK, Execute([]string{"seed", "-h"}))
+}
+
+func TestHealth(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	assert.Equal(t, exitOK, Execute([]string{"health", "--url", healthy.URL}))
+	// The flag form used by older container healthchecks still works
+	assert.Equal(t, exitOK, Execute([]string{"--health", "--url", healthy.URL}))
+	assert.Equal(t, exitFailure, Execute([]string{"health", "--url", unhealthy.URL}))
+	assert.Equal(t, exitFailure, Execute([]string{"health", "--url", "http://127.0.0.1:1/", "--timeout", time.Second.String()}))
+}
+
+func TestHealthURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:8000/readyz", healthURL(":8000", "/readyz"))
+	assert.Equal(t, "http://localhost:8000/healthz", healthURL("", "healthz"))
+	assert.Equal(t, "http://localhost:9000/readyz", healthURL("0.0.0.0:9000", "/readyz"))
+	assert.Equal(t, "http://127.0.0.1:9000/readyz", healthURL("127.0.0.1:9000", "/readyz"))
+}
+
+func TestParseImportRecord(t *testing.T) {
+	owner := primitive.NewObjectID()
+	headers := []string{"id", "title", "type", "price", "state", "city", "size", "bedrooms", "bathrooms",
+		"amenities", "furnished", "availableFrom", "listedBy", "tags", "colorTheme", "rating", "isVerified", "listingType"}
+	record := []string{"PROP1000", "Green sea.", "Bungalow", "23825834", "Tamil Nadu", "Coimbatore", "4102", "5", "2",
+		"lift|gym", "Unfurnished", "2025-10-14", "Builder", "gated-community|corner-plot", "#6ab45e", "4.7", "True", "rent"}
+
+	property := parseImportRecord(headers, record, owner)
+
+	assert.False(t, property.ID.IsZero())
+	assert.Equal(t, owner, property.CreatedBy)
+	assert.Equal(t, "Green sea.", property.Title)
+	assert.EqualValues(t, 23825834, property.Price)
+	assert.EqualValues(t, 4102, property.AreaSqFt)
+	assert.Equal(t, 5, property.Bedrooms)
+	assert.Equal(t, []string{"lift", "gym"}, property.Amenities)
+	assert.Equal(t, time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC), property.AvailableFrom)
+	assert.Equal(t, 4.7, property.Rating)
+	assert.True(t, property.IsVerified)
+	assert.Equal(t, "rent", property.ListingType)
+
+	// Malformed numbers fall back to zero instead of failing the import
+	record[3] = "n/a"
+	assert.Zero(t, parseImportRecord(headers, record, owner).Price)
+}
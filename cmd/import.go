@@ -1,128 +1,185 @@
 package main
 
 import (
-	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
 	"context"
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Import() {
-	f, err := os.Open("db424fd9fb74_1748258398689.csv")
+// Import handles `import`, bulk loading a CSV export into the properties collection
+func Import(args []string) error {
+	flags, profile := newFlagSet("import")
+	file := flags.String("file", "", "CSV file to import (required)")
+	owner := flags.String("owner", "", "ID of the user the properties are listed by (required)")
+	batchSize := flags.Int("batch", 1000, "number of documents per insert")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *file == "" || *owner == "" {
+		return usageError{"import requires --file and --owner"}
+	}
+	if *batchSize <= 0 {
+		return usageError{"--batch must be positive"}
+	}
+	ownerObjID, err := primitive.ObjectIDFromHex(*owner)
 	if err != nil {
-		log.Fatal(err)
+		return usageError{"--owner must be a user ID"}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
 	}
 	defer f.Close()
 
-	r := csv.NewReader(f)
-	headers, err := r.Read()
+	app, err := loadApp(*profile)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer app.Close()
 
 	ctx := context.Background()
+	if _, err := app.Users.GetByID(ctx, *owner); err != nil {
+		return fmt.Errorf("owner %s: %w", *owner, err)
+	}
 
-	db := database.GetMongoDB()
-	coll := db.Collection("properties")
+	start := time.Now()
+	imported, err := importProperties(ctx, app, f, ownerObjID, *batchSize)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Import complete", "properties", imported, "duration", time.Since(start).String())
+	return nil
+}
 
-	coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{Keys: bson.D{{Key: "city", Value: 1}}},
-		{Keys: bson.D{{Key: "price", Value: 1}}},
-		{Keys: bson.D{{Key: "state", Value: 1}}},
-		{Keys: bson.D{{Key: "type", Value: 1}}},
-	})
+// importProperties inserts the CSV rows in batches and evicts the cached lists they affect
+func importProperties(ctx context.Context, app *App, r io.Reader, owner primitive.ObjectID, batchSize int) (int, error) {
+	reader := csv.NewReader(r)
+	headers, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("reading CSV header: %w", err)
+	}
 
-	var docs []interface{}
+	collection := app.DB.Collection("properties")
+	imported := 0
+	batch := make([]models.Property, 0, batchSize)
 
-	for {
-		record, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Fatal(err)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
 		}
 
-		doc := bson.M{}
-		doc["_id"] = primitive.NewObjectID()
+		docs := make([]interface{}, len(batch))
+		for i := range batch {
+			docs[i] = batch[i]
+		}
+		if _, err := collection.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+		app.Cache.InvalidatePropertyLists(ctx, batch...)
 
-		for i, v := range record {
-			key := headers[i]
+		imported += len(batch)
+		slog.InfoContext(ctx, "Inserted documents", "count", len(batch))
+		batch = batch[:0]
+		return nil
+	}
 
-			if key == "propId" || key == "id" {
-				continue
-			}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
 
-			switch key {
-			case "price", "areaSqFt":
-
-				if val, err := strconv.ParseInt(v, 10, 64); err == nil {
-					doc[key] = val
-				} else {
-					doc[key] = 0
-				}
-			case "bedrooms", "bathrooms":
-
-				if val, err := strconv.Atoi(v); err == nil {
-					doc[key] = val
-				} else {
-					doc[key] = 0
-				}
-			case "rating":
-
-				if val, err := strconv.ParseFloat(v, 64); err == nil {
-					doc[key] = val
-				} else {
-					doc[key] = 0.0
-				}
-			case "isVerified":
-
-				doc[key] = v == "True"
-			case "amenities", "tags":
-
-				doc[key] = strings.Split(v, "|")
-			case "availableFrom":
-
-				if t, err := time.Parse("2006-01-02", v); err == nil {
-					doc[key] = t
-				} else {
-					doc[key] = time.Now()
-				}
-			default:
-				doc[key] = v
+		batch = append(batch, parseImportRecord(headers, record, owner))
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return imported, err
 			}
 		}
-		doc["createdAt"] = time.Now()
+	}
 
-		objID, _ := primitive.ObjectIDFromHex("6835c855bdcc74cfb350e6c4")
-		doc["createdBy"] = objID
+	if err := flush(); err != nil {
+		return imported, err
+	}
 
-		docs = append(docs, doc)
+	return imported, nil
+}
 
-		if len(docs) >= 1000 {
-			if _, err := coll.InsertMany(ctx, docs); err != nil {
-				log.Fatal(err)
-			}
-			log.Printf("Inserted %d documents", len(docs))
-			docs = docs[:0]
-		}
+// parseImportRecord maps one row of the listings export onto a property. Malformed
+// numbers fall back to zero, as the export is known to contain a few.
+func parseImportRecord(headers []string, record []string, owner primitive.ObjectID) models.Property {
+	now := time.Now()
+	property := models.Property{
+		ID:          primitive.NewObjectID(),
+		CreatedBy:   owner,
+		CreatedAt:   now,
+		Version:     1,
+		Status:      models.PropertyActive,
+		PublishedAt: &now,
+		RenewedAt:   &now,
 	}
 
-	if len(docs) > 0 {
-		if _, err := coll.InsertMany(ctx, docs); err != nil {
-			log.Fatal(err)
+	for i, value := range record {
+		if i >= len(headers) {
+			break
+		}
+
+		switch headers[i] {
+		case "title":
+			property.Title = value
+		case "type":
+			property.Type = value
+		case "price":
+			property.Price, _ = strconv.ParseInt(value, 10, 64)
+		case "state":
+			property.State = value
+		case "city":
+			property.City = value
+		case "size", "areaSqFt":
+			property.AreaSqFt, _ = strconv.ParseInt(value, 10, 64)
+		case "bedrooms":
+			property.Bedrooms, _ = strconv.Atoi(value)
+		case "bathrooms":
+			property.Bathrooms, _ = strconv.Atoi(value)
+		case "amenities":
+			property.Amenities = strings.Split(value, "|")
+		case "furnished":
+			property.Furnished = value
+		case "availableFrom":
+			if t, err := time.Parse("2006-01-02", value); err == nil {
+				property.AvailableFrom = t
+			} else {
+				property.AvailableFrom = time.Now()
+			}
+		case "listedBy":
+			property.ListedBy = value
+		case "tags":
+			property.Tags = strings.Split(value, "|")
+		case "colorTheme":
+			property.ColorTheme = value
+		case "rating":
+			property.Rating, _ = strconv.ParseFloat(value, 64)
+		case "isVerified":
+			property.IsVerified = value == "True"
+		case "listingType":
+			property.ListingType = value
 		}
-		log.Printf("Inserted %d documents", len(docs))
 	}
 
-	log.Println("Import complete!")
+	return property
 }
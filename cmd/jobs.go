@@ -0,0 +1,102 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	"Praiseson6065/Hypergro-assign/database"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartJobs launches the periodic background jobs configured for the environment.
+// They stop once ctx is cancelled; the returned WaitGroup is done when the last one
+// has returned, letting shutdown wait for a run in progress.
+func StartJobs(ctx context.Context, cfg config.JobsConfig, app *App) *sync.WaitGroup {
+	var wg sync.WaitGroup
+
+	if interval := cfg.RecommendationsInterval; interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runEvery(ctx, time.Duration(interval)*time.Minute, "personalised recommendations", func(ctx context.Context) error {
+				return precomputeRecommendations(ctx, app.Recommendations)
+			})
+		}()
+	}
+
+	if interval := cfg.CooccurrenceInterval; interval > 0 {
+		minSupport, topN := cfg.CooccurrenceMinSupport, cfg.CooccurrenceTopN
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runEvery(ctx, time.Duration(interval)*time.Minute, "favorites co-occurrence", func(ctx context.Context) error {
+				return computeCooccurrence(ctx, app.Recommendations, minSupport, topN)
+			})
+		}()
+	}
+
+	if interval := cfg.ExpiryInterval; interval > 0 {
+		ttl := time.Duration(cfg.ListingTTL) * 24 * time.Hour
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runEvery(ctx, time.Duration(interval)*time.Minute, "listing expiry", func(ctx context.Context) error {
+				return archiveExpiredListings(ctx, app.Properties, ttl)
+			})
+		}()
+	}
+
+	return &wg
+}
+
+// runEvery runs job immediately and then on every tick until ctx is cancelled.
+// A run in progress sees the cancellation through its context.
+func runEvery(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Job failed", "job", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func precomputeRecommendations(ctx context.Context, recommendations database.RecommendationRepository) error {
+	start := time.Now()
+	processed, err := recommendations.PrecomputePersonalised(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Precomputed recommendations", "users", processed, "duration", time.Since(start).String())
+	return nil
+}
+
+func computeCooccurrence(ctx context.Context, recommendations database.RecommendationRepository, minSupport int, topN int) error {
+	start := time.Now()
+	properties, err := recommendations.ComputeCooccurrence(ctx, minSupport, topN)
+	if err != nil {
+		return err
+	}
+	slog.Info("Computed also-saved neighbours", "properties", properties, "duration", time.Since(start).String())
+	return nil
+}
+
+// archiveExpiredListings archives the active listings not renewed within ttl
+func archiveExpiredListings(ctx context.Context, properties database.PropertyRepository, ttl time.Duration) error {
+	archived, err := properties.ArchiveExpired(ctx, time.Now().Add(-ttl))
+	if err != nil {
+		return err
+	}
+	if archived > 0 {
+		slog.Info("Archived expired listings", "properties", archived)
+	}
+	return nil
+}
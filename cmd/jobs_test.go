@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunEveryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+
+	done := make(chan struct{})
+	go func() {
+		runEvery(ctx, time.Hour, "test", func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runEvery did not return after cancellation")
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	assert.True(t, waitUntil(context.Background(), func() {}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	block := make(chan struct{})
+	defer close(block)
+	assert.False(t, waitUntil(ctx, func() { <-block }))
+}
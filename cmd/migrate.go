@@ -0,0 +1,109 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	"Praiseson6065/Hypergro-assign/database"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrate handles `migrate [up|down|status]`. Only MongoDB is needed, so it does not build a full App.
+func Migrate(args []string) error {
+	flags, profile := newFlagSet("migrate")
+	steps := flags.Int("steps", 1, "number of migrations to roll back with down")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	action := "up"
+	if flags.NArg() > 0 {
+		action = flags.Arg(0)
+		// Allow flags after the action too, as in `migrate down --steps 2`
+		if err := parseFlags(flags, flags.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if action != "up" && action != "down" && action != "status" {
+		return usageError{fmt.Sprintf("unknown migrate action %q, expected up, down or status", action)}
+	}
+
+	appConfig, err := config.Read(*profile)
+	if err != nil {
+		return err
+	}
+	if err := appConfig.ValidateMongoDB(); err != nil {
+		return err
+	}
+	cfg := database.NewDBConfig(appConfig)
+
+	mongoClient, err := database.ConnectMongo(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.DisconnectMongo(mongoClient)
+
+	migrator := database.NewMigrator(mongoClient.Database(cfg.MongoDBName), database.Migrations)
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Info("Applied migrations", "count", applied)
+	case "down":
+		rolledBack, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		slog.Info("Rolled back migrations", "count", rolledBack)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	}
+
+	return nil
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	applied, err := migrator.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, record := range applied {
+		fmt.Printf("applied  %3d_%s  %s\n", record.Version, record.Name, record.AppliedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		fmt.Printf("pending  %3d_%s\n", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+// ensureSchema fails startup when migrations are pending, unless the environment
+// opts into applying them itself
+func ensureSchema(cfg config.MigrationsConfig, db *mongo.Database) error {
+	migrator := database.NewMigrator(db, database.Migrations)
+	ctx := context.Background()
+
+	if cfg.AutoApply {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if applied > 0 {
+			slog.Info("Applied migrations on startup", "count", applied)
+		}
+		return nil
+	}
+
+	return migrator.CheckCurrent(ctx)
+}
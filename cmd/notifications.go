@@ -0,0 +1,37 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	notify "Praiseson6065/Hypergro-assign/notifications"
+	"time"
+)
+
+// StartNotifications wires the alert dispatcher into property updates and starts its workers
+func StartNotifications(cfg config.NotificationsConfig, app *App) *notify.Dispatcher {
+	channels := []notify.Channel{
+		notify.NewInboxChannel(app.Notifications),
+		notify.NewWebhookChannel(time.Duration(cfg.WebhookTimeout) * time.Second),
+	}
+
+	if smtp := cfg.SMTP; smtp.Host != "" {
+		channels = append(channels, notify.NewEmailChannel(notify.SMTPConfig{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			From:     smtp.From,
+			Timeout:  time.Duration(smtp.Timeout) * time.Second,
+		}))
+	}
+
+	dispatcher := notify.NewDispatcher(
+		app.Favorites,
+		cfg.QueueSize,
+		cfg.Workers,
+		channels...,
+	)
+	app.Properties.OnUpdated(dispatcher.PropertyUpdated)
+	dispatcher.Start()
+
+	return dispatcher
+}
@@ -2,56 +2,120 @@ package main
 
 import (
 	"Praiseson6065/Hypergro-assign/handlers/auth"
+	"Praiseson6065/Hypergro-assign/handlers/collections"
 	"Praiseson6065/Hypergro-assign/handlers/favorites"
+	"Praiseson6065/Hypergro-assign/handlers/health"
+	"Praiseson6065/Hypergro-assign/handlers/notifications"
 	"Praiseson6065/Hypergro-assign/handlers/property"
 	"Praiseson6065/Hypergro-assign/handlers/recommendations"
 	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRouter(r *gin.Engine) {
+// newEngine returns a gin engine whose handler contexts carry the request's
+// cancellation and deadline, so repositories stop work for clients that went away
+func newEngine() *gin.Engine {
+	r := gin.New()
+	r.ContextWithFallback = true
+	return r
+}
+
+func HealthRouter(r *gin.Engine, app *App) {
+	r.GET("/healthz", health.Liveness())
+	r.GET("/readyz", health.Readiness(app.Probe))
+}
+
+func AuthRouter(r *gin.Engine, app *App) {
 	authRoutes := r.Group("/auth")
+	authRoutes.Use(app.rateLimit("auth"))
 	{
-		authRoutes.POST("/signup", auth.UserSignup())
-		authRoutes.POST("/login", auth.UserLogin())
+		authRoutes.POST("/signup", auth.UserSignup(app.Users))
+		authRoutes.POST("/login", auth.UserLogin(app.Users))
 	}
 }
 
-func ApiRouter(r *gin.Engine) {
+func ApiRouter(r *gin.Engine, app *App) {
 
 	apiRoutes := r.Group("/api")
 
 	propertyRoutes := apiRoutes.Group("/properties")
 	{
 
-		propertyRoutes.GET("", property.ListProperties())
-		propertyRoutes.GET("/:id", property.GetProperty())
+		publicPropertyRoutes := propertyRoutes.Group("")
+		publicPropertyRoutes.Use(app.rateLimit("public"))
+		{
+			publicPropertyRoutes.GET("", property.ListProperties(app.Properties))
+			publicPropertyRoutes.GET("/:id", middleware.OptionalAuthenticator(), property.GetProperty(app.Properties, app.Views))
+			publicPropertyRoutes.GET("/:id/also-saved", property.ListAlsoSaved(app.Recommendations))
+		}
 
 		authenticatedPropertyRoutes := propertyRoutes.Group("")
-		authenticatedPropertyRoutes.Use(middleware.Authenicator())
+		authenticatedPropertyRoutes.Use(middleware.Authenicator(), app.rateLimit("authenticated"))
 		{
-			authenticatedPropertyRoutes.POST("", property.CreateProperty())
-			authenticatedPropertyRoutes.PUT("/:id", property.UpdateProperty())
-			authenticatedPropertyRoutes.DELETE("/:id", property.DeleteProperty())
-			authenticatedPropertyRoutes.POST("/import-csv", property.ImportPropertiesFromCSV())
+			authenticatedPropertyRoutes.POST("", property.CreateProperty(app.Properties))
+			authenticatedPropertyRoutes.PUT("/:id", property.UpdateProperty(app.Properties))
+			authenticatedPropertyRoutes.PATCH("/:id", property.PatchProperty(app.Properties))
+			authenticatedPropertyRoutes.DELETE("/:id", property.DeleteProperty(app.Properties))
+			authenticatedPropertyRoutes.POST("/:id/publish", property.TransitionProperty(app.Properties, models.PropertyActive))
+			authenticatedPropertyRoutes.POST("/:id/mark-under-offer", property.TransitionProperty(app.Properties, models.PropertyUnderOffer))
+			authenticatedPropertyRoutes.POST("/:id/mark-sold", property.TransitionProperty(app.Properties, models.PropertySold))
+			authenticatedPropertyRoutes.POST("/:id/mark-rented", property.TransitionProperty(app.Properties, models.PropertyRented))
+			authenticatedPropertyRoutes.POST("/:id/archive", property.TransitionProperty(app.Properties, models.PropertyArchived))
+			authenticatedPropertyRoutes.POST("/:id/renew", property.RenewProperty(app.Properties))
+			authenticatedPropertyRoutes.POST("/import-csv", app.rateLimit("import"), property.ImportPropertiesFromCSV(app.Properties))
 		}
 	}
 
 	userRoutes := apiRoutes.Group("/users")
-	userRoutes.Use(middleware.Authenicator())
+	userRoutes.Use(middleware.Authenicator(), app.rateLimit("authenticated"))
 	{
 
-		userRoutes.GET("/:userId/favorites", favorites.ListUserFavorites())
-		userRoutes.POST("/:userId/favorites", favorites.AddFavorite())
-		userRoutes.DELETE("/:userId/favorites/:propId", favorites.RemoveFavorite())
-		userRoutes.GET("/:userId/recommendations/received", recommendations.ListReceivedRecommendations())
+		userRoutes.GET("/:userId/favorites", favorites.ListUserFavorites(app.Favorites))
+		userRoutes.POST("/:userId/favorites", favorites.AddFavorite(app.Favorites))
+		userRoutes.DELETE("/:userId/favorites/:propId", favorites.RemoveFavorite(app.Favorites))
+		userRoutes.GET("/:userId/recommendations/received", recommendations.ListReceivedRecommendations(app.Recommendations))
+
+		userRoutes.GET("/:userId/collections", collections.ListCollections(app.Favorites))
+		userRoutes.POST("/:userId/collections", collections.CreateCollection(app.Favorites))
+		userRoutes.GET("/:userId/collections/:collectionId", collections.GetCollection(app.Favorites))
+		userRoutes.PUT("/:userId/collections/:collectionId", collections.RenameCollection(app.Favorites))
+		userRoutes.DELETE("/:userId/collections/:collectionId", collections.DeleteCollection(app.Favorites))
+		userRoutes.POST("/:userId/collections/:collectionId/items", collections.AddItem(app.Favorites))
+		userRoutes.PUT("/:userId/collections/:collectionId/items/:propId", collections.UpdateItemNote(app.Favorites))
+		userRoutes.DELETE("/:userId/collections/:collectionId/items/:propId", collections.RemoveItem(app.Favorites))
+		userRoutes.POST("/:userId/collections/:collectionId/items/:propId/comments", collections.AddComment(app.Favorites))
+		userRoutes.DELETE("/:userId/collections/:collectionId/items/:propId/comments/:commentId", collections.DeleteComment(app.Favorites))
+		userRoutes.PUT("/:userId/collections/:collectionId/items/:propId/reaction", collections.SetReaction(app.Favorites))
+		userRoutes.POST("/:userId/collections/:collectionId/share-link", collections.CreateShareLink(app.Favorites))
+		userRoutes.DELETE("/:userId/collections/:collectionId/share-link", collections.RevokeShareLink(app.Favorites))
+		userRoutes.POST("/:userId/collections/:collectionId/collaborators", collections.AddCollaborator(app.Favorites))
+		userRoutes.DELETE("/:userId/collections/:collectionId/collaborators/:collaboratorId", collections.RemoveCollaborator(app.Favorites))
+	}
+
+	meRoutes := apiRoutes.Group("/me")
+	meRoutes.Use(middleware.Authenicator(), app.rateLimit("authenticated"))
+	{
+		meRoutes.GET("/properties", property.GetUserProperties(app.Properties))
+		meRoutes.GET("/recommended", recommendations.ListPersonalisedRecommendations(app.Recommendations))
+		meRoutes.GET("/shared-collections", collections.ListSharedWithMe(app.Favorites))
+		meRoutes.GET("/notifications", notifications.ListNotifications(app.Notifications))
+		meRoutes.POST("/notifications/:id/read", notifications.MarkRead(app.Notifications))
+		meRoutes.GET("/notification-preferences", notifications.GetPreferences(app.Users))
+		meRoutes.PUT("/notification-preferences", notifications.UpdatePreferences(app.Users))
+	}
+
+	sharedRoutes := apiRoutes.Group("/shared")
+	sharedRoutes.Use(app.rateLimit("public"))
+	{
+		sharedRoutes.GET("/collections/:token", collections.GetSharedCollection(app.Favorites))
 	}
 
 	recommendationRoutes := apiRoutes.Group("/recommendations")
-	recommendationRoutes.Use(middleware.Authenicator())
+	recommendationRoutes.Use(middleware.Authenicator(), app.rateLimit("authenticated"))
 	{
-		recommendationRoutes.POST("", recommendations.CreateRecommendation())
+		recommendationRoutes.POST("", app.rateLimit("recommendations"), recommendations.CreateRecommendation(app.Recommendations))
 	}
 
 }
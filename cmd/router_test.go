@@ -0,0 +1,1299 @@
+package main
+
+import (
+	"Praiseson6065/Hypergro-assign/cache"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/handlers/health"
+	"Praiseson6065/Hypergro-assign/handlers/property"
+	"Praiseson6065/Hypergro-assign/logging"
+	"Praiseson6065/Hypergro-assign/metrics"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+	"Praiseson6065/Hypergro-assign/views"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testServer struct {
+	t      *testing.T
+	app    *App
+	router *gin.Engine
+}
+
+// newTestServer builds the routes over in-memory repositories. configure may adjust
+// the App, e.g. to enable rate limiting, before the routes are built.
+func newTestServer(t *testing.T, configure ...func(app *App)) *testServer {
+	gin.SetMode(gin.TestMode)
+	middleware.ConfigureJWT("test-secret", time.Hour)
+
+	store := database.NewMemoryStore()
+	app := &App{
+		Cache:           database.NewCacheLayer(cache.NewMemory(1000), false),
+		Properties:      database.NewMemoryPropertyRepository(store),
+		Users:           database.NewMemoryUserRepository(store),
+		Favorites:       database.NewMemoryFavoriteRepository(store),
+		Recommendations: database.NewMemoryRecommendationRepository(store),
+		Notifications:   database.NewMemoryNotificationRepository(store),
+		Probe:           health.NewProbe(200 * time.Millisecond),
+	}
+	app.Views = views.NewRecorder(app.Users, 100, 1)
+	app.Views.Start()
+	t.Cleanup(app.Views.Stop)
+	for _, c := range configure {
+		c(app)
+	}
+
+	r := newEngine()
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Errors())
+	r.NoRoute(middleware.NoRoute())
+	HealthRouter(r, app)
+	AuthRouter(r, app)
+	ApiRouter(r, app)
+
+	return &testServer{t: t, app: app, router: r}
+}
+
+// problemFields lists the fields named in a problem's field errors
+func problemFields(problem map[string]interface{}) []interface{} {
+	var fields []interface{}
+	errs, _ := problem["errors"].([]interface{})
+	for _, e := range errs {
+		fields = append(fields, e.(map[string]interface{})["field"])
+	}
+	return fields
+}
+
+// token signs a bearer token with the test JWT settings
+func (s *testServer) token(userID string) string {
+	token, err := middleware.GenerateToken(userID)
+	require.NoError(s.t, err)
+	return token
+}
+
+// anyVersion lets a property write apply whatever the property's version
+var anyVersion = map[string]string{"If-Match": "*"}
+
+func (s *testServer) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	return s.requestWith(method, path, token, nil, body)
+}
+
+// requestWith sends body as JSON with extra headers, which may replace its Content-Type
+func (s *testServer) requestWith(method, path, token string, headers map[string]string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(s.t, err)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *testServer) createUser(name string) string {
+	id, err := s.app.Users.FirstOrCreate(context.Background(), &models.User{Name: name, Email: name + "@example.com"})
+	require.NoError(s.t, err)
+	return id
+}
+
+func (s *testServer) createProperty(ownerID string, property models.Property) string {
+	ownerObjID, err := primitive.ObjectIDFromHex(ownerID)
+	require.NoError(s.t, err)
+	property.CreatedBy = ownerObjID
+
+	created, err := s.app.Properties.Create(context.Background(), &property)
+	require.NoError(s.t, err)
+	return created.ID.Hex()
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	return body
+}
+
+func TestHealthRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	var redisErr error
+	s.app.Probe.Register("mongo", func(ctx context.Context) error { return nil })
+	s.app.Probe.Register("redis", func(ctx context.Context) error { return redisErr })
+	s.app.Probe.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	t.Run("liveness ignores dependencies", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/healthz", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("readiness reports each dependency", func(t *testing.T) {
+		redisErr = errors.New("connection refused")
+
+		w := s.request(http.MethodGet, "/readyz", "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		body := decode(t, w)
+		assert.Equal(t, health.StatusDegraded, body["status"])
+		checks := body["checks"].(map[string]interface{})
+		assert.Equal(t, health.StatusUp, checks["mongo"].(map[string]interface{})["status"])
+		assert.Equal(t, "connection refused", checks["redis"].(map[string]interface{})["error"])
+		// Hanging checks are cut off by the probe timeout
+		assert.Equal(t, health.StatusDown, checks["slow"].(map[string]interface{})["status"])
+	})
+
+	t.Run("readiness flips during shutdown", func(t *testing.T) {
+		s := newTestServer(t)
+		s.app.Probe.Register("mongo", func(ctx context.Context) error { return nil })
+
+		assert.Equal(t, http.StatusOK, s.request(http.MethodGet, "/readyz", "", nil).Code)
+
+		s.app.Probe.SetShuttingDown()
+		w := s.request(http.MethodGet, "/readyz", "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, health.StatusShuttingDown, decode(t, w)["status"])
+		assert.Equal(t, http.StatusOK, s.request(http.MethodGet, "/healthz", "", nil).Code)
+	})
+}
+
+func TestAuthRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	credentials := gin.H{"name": "Asha", "email": "asha@example.com", "password": "secret"}
+
+	w := s.request(http.MethodPost, "/auth/signup", "", credentials)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	userID := decode(t, w)["userId"]
+
+	t.Run("signup is idempotent per email", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/auth/signup", "", credentials)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, userID, decode(t, w)["userId"])
+	})
+
+	t.Run("signup requires all fields", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/auth/signup", "", gin.H{"email": "x@example.com"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("login returns a usable token", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/auth/login", "", gin.H{"email": "asha@example.com", "password": "secret"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		token, _ := decode(t, w)["token"].(string)
+		require.NotEmpty(t, token)
+		validated, err := middleware.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, validated)
+	})
+
+	t.Run("login rejects a wrong password", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/auth/login", "", gin.H{"email": "asha@example.com", "password": "nope"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("login rejects an unknown email", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/auth/login", "", gin.H{"email": "ghost@example.com", "password": "secret"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "invalid_credentials", decode(t, w)["code"])
+	})
+}
+
+func TestPropertyRoutes(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	other := s.createUser("other")
+
+	apartment := s.createProperty(owner, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", State: "MH", Price: 5000000, Bedrooms: 2})
+	s.createProperty(owner, models.Property{Title: "Villa", Type: "Villa", City: "Goa", State: "GA", Price: 25000000, Bedrooms: 4})
+
+	t.Run("list and filter", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/properties", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 2, decode(t, w)["count"])
+
+		w = s.request(http.MethodGet, "/api/properties?city=Pune&maxPrice=6000000", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 1, decode(t, w)["count"])
+
+		w = s.request(http.MethodGet, "/api/properties?bedrooms=3", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 0, decode(t, w)["count"])
+	})
+
+	t.Run("get anonymously and signed in", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/properties/"+apartment, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		property := decode(t, w)["property"].(map[string]interface{})
+		assert.Equal(t, "Flat", property["title"])
+
+		w = s.request(http.MethodGet, "/api/properties/"+apartment, s.token(other), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The view is recorded in the background
+		assert.Eventually(t, func() bool {
+			user, err := s.app.Users.GetByID(context.Background(), other)
+			return err == nil && len(user.RecentlyViewed) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("get unknown property", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/properties/"+primitive.NewObjectID().Hex(), "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "property_not_found", decode(t, w)["code"])
+	})
+
+	t.Run("also saved", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/properties/"+apartment+"/also-saved", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 0, decode(t, w)["count"])
+
+		w = s.request(http.MethodGet, "/api/properties/not-an-id/also-saved", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("writes require authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPost, "/api/properties", "", gin.H{"title": "x"}).Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPut, "/api/properties/"+apartment, "", gin.H{"price": 1}).Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodDelete, "/api/properties/"+apartment, "", nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPost, "/api/properties/import-csv", "", nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPost, "/api/properties", "not-a-token", gin.H{"title": "x"}).Code)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/properties", s.token(owner), gin.H{"title": "Studio", "type": "Studio", "state": "Maharashtra", "city": "Pune", "price": 100})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		data := decode(t, w)["data"].(map[string]interface{})
+		assert.Equal(t, owner, data["createdBy"])
+	})
+
+	t.Run("create validates every field", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/properties", s.token(owner), gin.H{
+			"title": "Plot\r\nBcc: all@example.com", "type": "Land", "state": "Maharashtra", "city": "Pune", "price": -5,
+			"bedrooms": -1, "furnished": "Partly", "listingType": "lease", "colorTheme": "blue",
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		problem := decode(t, w)
+		assert.Equal(t, "invalid_body", problem["code"])
+		assert.ElementsMatch(t, []interface{}{"title", "type", "price", "bedrooms", "furnished", "listingType", "colorTheme"}, problemFields(problem))
+	})
+
+	t.Run("server-owned fields cannot be written", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/properties", s.token(owner), gin.H{
+			"title": "Flat", "type": "Apartment", "state": "Maharashtra", "city": "Pune", "price": 100,
+			"isVerified": true, "createdBy": other, "injected": "x",
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []interface{}{"createdBy", "injected", "isVerified"}, problemFields(decode(t, w)))
+
+		for _, body := range []gin.H{{"createdBy": other}, {"_id": apartment}, {"rating": 5}, {"isVerified": true}, {"$where": "1"}} {
+			w = s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(owner), anyVersion, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+
+		current, err := s.app.Properties.GetByID(context.Background(), apartment)
+		require.NoError(t, err)
+		assert.Equal(t, owner, current.CreatedBy.Hex())
+		assert.False(t, current.IsVerified)
+	})
+
+	t.Run("update validates set fields", func(t *testing.T) {
+		w := s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(owner), anyVersion, gin.H{"price": 0, "title": "", "bathrooms": -2})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.ElementsMatch(t, []interface{}{"price", "title", "bathrooms"}, problemFields(decode(t, w)))
+
+		w = s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(owner), anyVersion, gin.H{"price": "cheap"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []interface{}{"price"}, problemFields(decode(t, w)))
+
+		w = s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(owner), anyVersion, gin.H{})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "nothing_to_update", decode(t, w)["code"])
+	})
+
+	t.Run("update is limited to the owner", func(t *testing.T) {
+		w := s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(other), anyVersion, gin.H{"price": 1})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = s.requestWith(http.MethodPut, "/api/properties/"+apartment, s.token(owner), anyVersion, gin.H{"price": 4500000})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		property := decode(t, w)["property"].(map[string]interface{})
+		assert.EqualValues(t, 4500000, property["price"])
+		assert.Equal(t, "Flat", property["title"])
+
+		w = s.requestWith(http.MethodPut, "/api/properties/not-an-id", s.token(owner), anyVersion, gin.H{"price": 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delete is limited to the owner", func(t *testing.T) {
+		doomed := s.createProperty(owner, models.Property{Title: "Shack", Type: "House", City: "Pune", Price: 10})
+
+		w := s.requestWith(http.MethodDelete, "/api/properties/"+doomed, s.token(other), anyVersion, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = s.requestWith(http.MethodDelete, "/api/properties/"+doomed, s.token(owner), anyVersion, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.requestWith(http.MethodDelete, "/api/properties/"+doomed, s.token(owner), anyVersion, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("import csv", func(t *testing.T) {
+		csv := "title,type,price,state,city,bedrooms\n" +
+			"Loft,Apartment,3000000,KA,Bengaluru,1\n" +
+			"Broken,Apartment,-5,KA,Bengaluru,1\n"
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("properties_csv", "listings.csv")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(csv))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/properties/import-csv", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+s.token(owner))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		result := decode(t, w)
+		assert.EqualValues(t, 1, result["total_created"])
+		assert.Len(t, result["errors"], 1)
+
+		w = s.request(http.MethodPost, "/api/properties/import-csv", s.token(owner), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPropertyPatch(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	other := s.createUser("other")
+	flat := s.createProperty(owner, models.Property{
+		Title: "Flat", Type: "Apartment", City: "Pune", State: "MH", Price: 5000000,
+		Furnished: "Semi", Amenities: []string{"lift", "gym"}, Tags: []string{"quiet"},
+	})
+	path := "/api/properties/" + flat
+
+	mergePatch := func(token string, body interface{}) *httptest.ResponseRecorder {
+		return s.requestWith(http.MethodPatch, path, token, map[string]string{"Content-Type": property.MergePatchContentType, "If-Match": "*"}, body)
+	}
+	jsonPatch := func(token string, body interface{}) *httptest.ResponseRecorder {
+		return s.requestWith(http.MethodPatch, path, token, map[string]string{"Content-Type": property.JSONPatchContentType, "If-Match": "*"}, body)
+	}
+	stored := func() *models.Property {
+		current, err := s.app.Properties.GetByID(context.Background(), flat)
+		require.NoError(t, err)
+		return current
+	}
+
+	t.Run("requires a patch media type", func(t *testing.T) {
+		w := s.requestWith(http.MethodPatch, path, s.token(owner), anyVersion, gin.H{"price": 1})
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Header().Get("Accept-Patch"), property.JSONPatchContentType)
+	})
+
+	t.Run("is limited to the owner", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, mergePatch("", gin.H{"price": 1}).Code)
+		assert.Equal(t, http.StatusForbidden, mergePatch(s.token(other), gin.H{"price": 1}).Code)
+		assert.Equal(t, http.StatusForbidden, jsonPatch(s.token(other), []gin.H{{"op": "replace", "path": "/price", "value": 1}}).Code)
+	})
+
+	t.Run("merge patch sets and removes fields", func(t *testing.T) {
+		w := mergePatch(s.token(owner), gin.H{"price": 5500000, "furnished": nil, "tags": []string{"quiet", "sunny"}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		current := stored()
+		assert.EqualValues(t, 5500000, current.Price)
+		assert.Empty(t, current.Furnished)
+		assert.Equal(t, []string{"quiet", "sunny"}, current.Tags)
+		assert.Equal(t, "Flat", current.Title)
+	})
+
+	t.Run("merge patch is validated against the writable fields", func(t *testing.T) {
+		w := mergePatch(s.token(owner), gin.H{"title": nil, "price": -1, "isVerified": true})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []interface{}{"isVerified"}, problemFields(decode(t, w)))
+
+		w = mergePatch(s.token(owner), gin.H{"title": nil, "price": -1})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []interface{}{"title"}, problemFields(decode(t, w)))
+
+		w = mergePatch(s.token(owner), gin.H{"price": -1, "colorTheme": "red"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.ElementsMatch(t, []interface{}{"price", "colorTheme"}, problemFields(decode(t, w)))
+	})
+
+	t.Run("json patch edits one array element", func(t *testing.T) {
+		w := jsonPatch(s.token(owner), []gin.H{
+			{"op": "add", "path": "/amenities/-", "value": "pool"},
+			{"op": "add", "path": "/tags/-", "value": "corner"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []string{"lift", "gym", "pool"}, stored().Amenities)
+
+		w = jsonPatch(s.token(owner), []gin.H{{"op": "remove", "path": "/amenities/1"}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []string{"lift", "pool"}, stored().Amenities)
+		assert.Equal(t, []string{"quiet", "sunny", "corner"}, stored().Tags)
+	})
+
+	t.Run("json patch replaces, tests and removes fields", func(t *testing.T) {
+		w := jsonPatch(s.token(owner), []gin.H{
+			{"op": "test", "path": "/price", "value": 5500000},
+			{"op": "replace", "path": "/price", "value": 5400000},
+			{"op": "replace", "path": "/amenities/0", "value": "elevator"},
+			{"op": "add", "path": "/furnished", "value": "Furnished"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		current := stored()
+		assert.EqualValues(t, 5400000, current.Price)
+		assert.Equal(t, []string{"elevator", "pool"}, current.Amenities)
+		assert.Equal(t, "Furnished", current.Furnished)
+	})
+
+	t.Run("failed tests apply nothing", func(t *testing.T) {
+		w := jsonPatch(s.token(owner), []gin.H{
+			{"op": "replace", "path": "/title", "value": "Changed"},
+			{"op": "test", "path": "/price", "value": 1},
+		})
+		require.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "patch_test_failed", decode(t, w)["code"])
+		assert.Equal(t, "Flat", stored().Title)
+	})
+
+	t.Run("json patch is validated against the writable fields", func(t *testing.T) {
+		cases := map[string][]gin.H{
+			"read-only field": {{"op": "replace", "path": "/isVerified", "value": true}},
+			"unknown field":   {{"op": "add", "path": "/owner", "value": "x"}},
+			"required field":  {{"op": "remove", "path": "/title"}},
+			"invalid value":   {{"op": "replace", "path": "/type", "value": "Castle"}},
+			"invalid element": {{"op": "add", "path": "/amenities/-", "value": 7}},
+			"missing element": {{"op": "remove", "path": "/amenities/9"}},
+			"nested path":     {{"op": "add", "path": "/price/0", "value": 1}},
+			"unknown op":      {{"op": "merge", "path": "/price", "value": 1}},
+			"missing value":   {{"op": "replace", "path": "/price"}},
+			"null required":   {{"op": "replace", "path": "/city", "value": nil}},
+		}
+		for name, patch := range cases {
+			w := jsonPatch(s.token(owner), patch)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+			assert.Equal(t, "invalid_patch", decode(t, w)["code"], name)
+		}
+		assert.Equal(t, "Flat", stored().Title)
+	})
+}
+
+func TestPropertyVersions(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	flat := s.createProperty(owner, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", State: "MH", Price: 100})
+	path := "/api/properties/" + flat
+	ifMatch := func(etag string) map[string]string {
+		return map[string]string{"If-Match": etag}
+	}
+
+	t.Run("get returns the version as an ETag", func(t *testing.T) {
+		w := s.request(http.MethodGet, path, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+		for _, etag := range []string{`"1"`, `W/"1"`, `"7", "1"`, "*"} {
+			w = s.requestWith(http.MethodGet, path, "", map[string]string{"If-None-Match": etag}, nil)
+			assert.Equal(t, http.StatusNotModified, w.Code, etag)
+			assert.Empty(t, w.Body.String())
+			assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+		}
+
+		w = s.requestWith(http.MethodGet, path, "", map[string]string{"If-None-Match": `"2"`}, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("writes require If-Match", func(t *testing.T) {
+		w := s.request(http.MethodPut, path, s.token(owner), gin.H{"price": 200})
+		require.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Equal(t, "if_match_required", decode(t, w)["code"])
+
+		assert.Equal(t, http.StatusPreconditionRequired, s.request(http.MethodDelete, path, s.token(owner), nil).Code)
+	})
+
+	t.Run("updates move the version on", func(t *testing.T) {
+		// Any tag in a list may name the current version
+		w := s.requestWith(http.MethodPut, path, s.token(owner), ifMatch(`"7", W/"1", "1"`), gin.H{"price": 200})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+		w = s.requestWith(http.MethodPatch, path, s.token(owner), map[string]string{
+			"Content-Type": property.MergePatchContentType, "If-Match": `"2"`,
+		}, gin.H{"price": 300})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+		w = s.request(http.MethodGet, path, "", nil)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("stale versions are refused", func(t *testing.T) {
+		for _, etag := range []string{`"2"`, `W/"3"`, `"abc"`, `"1", "2"`} {
+			w := s.requestWith(http.MethodPut, path, s.token(owner), ifMatch(etag), gin.H{"price": 1})
+			require.Equal(t, http.StatusPreconditionFailed, w.Code, etag)
+			assert.Equal(t, "version_mismatch", decode(t, w)["code"])
+		}
+
+		w := s.requestWith(http.MethodPatch, path, s.token(owner), map[string]string{
+			"Content-Type": property.JSONPatchContentType, "If-Match": `"2"`,
+		}, []gin.H{{"op": "replace", "path": "/price", "value": 1}})
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		w = s.requestWith(http.MethodDelete, path, s.token(owner), ifMatch(`"2"`), nil)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		current, err := s.app.Properties.GetByID(context.Background(), flat)
+		require.NoError(t, err)
+		assert.EqualValues(t, 300, current.Price)
+	})
+
+	t.Run("delete with the current version", func(t *testing.T) {
+		w := s.requestWith(http.MethodDelete, path, s.token(owner), ifMatch(`"3"`), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusNotFound, s.request(http.MethodGet, path, "", nil).Code)
+	})
+}
+
+func TestPropertyLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	other := s.createUser("other")
+	house := s.createProperty(owner, models.Property{Title: "House", Type: "Villa", City: "Pune", State: "MH", Price: 100, ListingType: "sale"})
+	path := "/api/properties/" + house
+
+	listed := func(query string) []string {
+		w := s.request(http.MethodGet, "/api/properties"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var ids []string
+		for _, p := range decode(t, w)["properties"].([]interface{}) {
+			ids = append(ids, p.(map[string]interface{})["id"].(string))
+		}
+		return ids
+	}
+
+	t.Run("new listings are active", func(t *testing.T) {
+		w := s.request(http.MethodGet, path, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		property := decode(t, w)["property"].(map[string]interface{})
+		assert.Equal(t, models.PropertyActive, property["status"])
+		assert.NotEmpty(t, property["publishedAt"])
+		assert.NotEmpty(t, property["renewedAt"])
+		assert.Contains(t, listed(""), house)
+	})
+
+	t.Run("drafts are hidden until published", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/properties", s.token(owner), gin.H{
+			"title": "Plot", "type": "Villa", "price": 100, "state": "MH", "city": "Pune", "status": "draft",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		draft := decode(t, w)["data"].(map[string]interface{})["id"].(string)
+
+		assert.NotContains(t, listed(""), draft)
+		assert.Equal(t, http.StatusNotFound, s.request(http.MethodGet, "/api/properties/"+draft, "", nil).Code)
+		assert.Equal(t, http.StatusOK, s.request(http.MethodGet, "/api/properties/"+draft, s.token(owner), nil).Code)
+
+		w = s.request(http.MethodPost, "/api/properties/"+draft+"/publish", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, listed(""), draft)
+	})
+
+	t.Run("only the owner changes the status", func(t *testing.T) {
+		w := s.request(http.MethodPost, path+"/mark-sold", s.token(other), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPost, path+"/mark-sold", "", nil).Code)
+	})
+
+	t.Run("status is not writable by updates", func(t *testing.T) {
+		w := s.requestWith(http.MethodPut, path, s.token(owner), map[string]string{"If-Match": "*"}, gin.H{"status": "sold"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		problem := decode(t, w)
+		assert.Equal(t, []interface{}{"status"}, problemFields(problem))
+		assert.Equal(t, "is read-only", problem["errors"].([]interface{})[0].(map[string]interface{})["message"])
+	})
+
+	t.Run("transitions follow the state machine", func(t *testing.T) {
+		w := s.request(http.MethodPost, path+"/mark-rented", s.token(owner), nil)
+		require.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "invalid_status_transition", decode(t, w)["code"])
+
+		w = s.request(http.MethodPost, path+"/mark-under-offer", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, models.PropertyUnderOffer, decode(t, w)["property"].(map[string]interface{})["status"])
+		assert.NotContains(t, listed(""), house)
+		assert.Contains(t, listed("?status=under_offer"), house)
+
+		w = s.request(http.MethodPost, path+"/renew", s.token(owner), nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = s.requestWith(http.MethodPost, path+"/mark-sold", s.token(owner), map[string]string{"If-Match": `"1"`}, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		w = s.request(http.MethodPost, path+"/mark-sold", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		property := decode(t, w)["property"].(map[string]interface{})
+		assert.Equal(t, models.PropertySold, property["status"])
+		assert.NotEmpty(t, property["soldAt"])
+		assert.NotEmpty(t, property["underOfferAt"])
+		assert.Contains(t, listed("?status=sold"), house)
+
+		w = s.request(http.MethodPost, path+"/publish", s.token(owner), nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("drafts and archived listings are not public", func(t *testing.T) {
+		for _, status := range []string{"draft", "archived", "bogus"} {
+			w := s.request(http.MethodGet, "/api/properties?status="+status, "", nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, status)
+		}
+	})
+
+	t.Run("listings not renewed expire", func(t *testing.T) {
+		stale := s.createProperty(owner, models.Property{Title: "Stale", Type: "Villa", City: "Pune", Price: 100})
+		fresh := s.createProperty(owner, models.Property{Title: "Fresh", Type: "Villa", City: "Pune", Price: 100})
+		// Stored times keep milliseconds, so the renewal must come later than that
+		time.Sleep(2 * time.Millisecond)
+
+		w := s.request(http.MethodPost, "/api/properties/"+fresh+"/renew", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		renewed, err := s.app.Properties.GetByID(context.Background(), fresh)
+		require.NoError(t, err)
+
+		archived, err := s.app.Properties.ArchiveExpired(context.Background(), *renewed.RenewedAt)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, archived)
+
+		expired, err := s.app.Properties.GetByID(context.Background(), stale)
+		require.NoError(t, err)
+		assert.Equal(t, models.PropertyArchived, expired.Status)
+		assert.NotNil(t, expired.ArchivedAt)
+		assert.NotContains(t, listed(""), stale)
+		assert.Contains(t, listed(""), fresh)
+
+		w = s.request(http.MethodPost, "/api/properties/"+stale+"/publish", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, listed(""), stale)
+	})
+
+	t.Run("unpublished listings are never recommended", func(t *testing.T) {
+		seed := s.createProperty(other, models.Property{Title: "Seed", Type: "Studio", City: "Nagpur", Price: 1000, Rating: 3})
+		visible := s.createProperty(other, models.Property{Title: "Visible", Type: "Studio", City: "Nagpur", Price: 1000, Rating: 4})
+		hidden := []string{
+			s.createProperty(other, models.Property{Title: "Draft", Type: "Studio", City: "Nagpur", Price: 1000, Rating: 5}),
+			s.createProperty(other, models.Property{Title: "Archived", Type: "Studio", City: "Nagpur", Price: 1000, Rating: 5, Status: models.PropertyArchived}),
+		}
+
+		// Enough users save every listing together to pass any support threshold
+		for i := 0; i < 5; i++ {
+			saver := s.createUser(fmt.Sprintf("saver%d", i))
+			for _, id := range append([]string{seed, visible}, hidden...) {
+				require.NoError(t, s.app.Favorites.AddFavorite(context.Background(), saver, id))
+			}
+		}
+		// Drafts cannot be saved by other users, but may have been before that was refused
+		_, err := s.app.Properties.Update(context.Background(), other, hidden[0], database.AnyVersion, database.PropertyUpdate{
+			Set: map[string]interface{}{"status": models.PropertyDraft},
+		})
+		require.NoError(t, err)
+		_, err = s.app.Recommendations.ComputeCooccurrence(context.Background(), 1, 10)
+		require.NoError(t, err)
+
+		w := s.request(http.MethodGet, "/api/properties/"+seed+"/also-saved", "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var alsoSaved []string
+		for _, p := range decode(t, w)["properties"].([]interface{}) {
+			alsoSaved = append(alsoSaved, p.(map[string]interface{})["property"].(map[string]interface{})["id"].(string))
+		}
+		assert.Contains(t, alsoSaved, visible)
+
+		// A user who only saved the seed is recommended the other listings in Nagpur
+		fan := s.createUser("fan")
+		require.NoError(t, s.app.Favorites.AddFavorite(context.Background(), fan, seed))
+		w = s.request(http.MethodGet, "/api/me/recommended", s.token(fan), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var recommended []string
+		for _, r := range decode(t, w)["recommendations"].([]interface{}) {
+			recommended = append(recommended, r.(map[string]interface{})["property"].(map[string]interface{})["id"].(string))
+		}
+		assert.Contains(t, recommended, visible)
+
+		for _, id := range hidden {
+			assert.NotContains(t, alsoSaved, id)
+			assert.NotContains(t, recommended, id)
+		}
+	})
+
+	t.Run("owners list their listings in every status", func(t *testing.T) {
+		draft := s.createProperty(owner, models.Property{Title: "Unfinished", Type: "Villa", City: "Pune", Price: 100, Status: models.PropertyDraft})
+		archived := s.createProperty(owner, models.Property{Title: "Old", Type: "Villa", City: "Pune", Price: 100, Status: models.PropertyArchived})
+
+		mine := func(userID string) map[string]string {
+			w := s.request(http.MethodGet, "/api/me/properties", s.token(userID), nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			statuses := map[string]string{}
+			for _, p := range decode(t, w)["properties"].([]interface{}) {
+				property := p.(map[string]interface{})
+				statuses[property["id"].(string)] = property["status"].(string)
+			}
+			return statuses
+		}
+
+		owned := mine(owner)
+		assert.Equal(t, models.PropertyDraft, owned[draft])
+		assert.Equal(t, models.PropertyArchived, owned[archived])
+		assert.Equal(t, models.PropertySold, owned[house])
+
+		assert.NotContains(t, mine(other), draft)
+		assert.NotContains(t, mine(other), house)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodGet, "/api/me/properties", "", nil).Code)
+	})
+}
+
+func TestFavoriteRoutes(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createUser("saver")
+	other := s.createUser("other")
+	property := s.createProperty(other, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", Price: 100})
+	favoritesPath := "/api/users/" + user + "/favorites"
+
+	t.Run("requires authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodGet, favoritesPath, "", nil).Code)
+	})
+
+	t.Run("only the owner can read or change favorites", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, s.request(http.MethodGet, favoritesPath, s.token(other), nil).Code)
+		assert.Equal(t, http.StatusForbidden, s.request(http.MethodPost, favoritesPath, s.token(other), gin.H{"propertyId": property}).Code)
+		assert.Equal(t, http.StatusForbidden, s.request(http.MethodDelete, favoritesPath+"/"+property, s.token(other), nil).Code)
+	})
+
+	t.Run("add, list and remove", func(t *testing.T) {
+		w := s.request(http.MethodPost, favoritesPath, s.token(user), gin.H{"propertyId": property})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// Saving twice is rejected, like $addToSet with a guard
+		w = s.request(http.MethodPost, favoritesPath, s.token(user), gin.H{"propertyId": property})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = s.request(http.MethodGet, favoritesPath, s.token(user), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 1, decode(t, w)["count"])
+
+		w = s.request(http.MethodDelete, favoritesPath+"/"+property, s.token(user), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodDelete, favoritesPath+"/"+property, s.token(user), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unknown property", func(t *testing.T) {
+		w := s.request(http.MethodPost, favoritesPath, s.token(user), gin.H{"propertyId": primitive.NewObjectID().Hex()})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRecommendationRoutes(t *testing.T) {
+	s := newTestServer(t)
+	sender := s.createUser("sender")
+	recipient := s.createUser("recipient")
+	property := s.createProperty(sender, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", Price: 100})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodPost, "/api/recommendations", "", gin.H{}).Code)
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodGet, "/api/users/"+recipient+"/recommendations/received", "", nil).Code)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/recommendations", s.token(sender), gin.H{"propertyId": property})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = s.request(http.MethodPost, "/api/recommendations", s.token(sender), gin.H{"toUserId": recipient})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = s.request(http.MethodPost, "/api/recommendations", s.token(sender), gin.H{"toUserId": primitive.NewObjectID().Hex(), "propertyId": property})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("recommend and list received", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/recommendations", s.token(sender), gin.H{"toUserId": recipient, "propertyId": property})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = s.request(http.MethodGet, "/api/users/"+recipient+"/recommendations/received", s.token(recipient), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 1, decode(t, w)["count"])
+	})
+
+	t.Run("received recommendations are private", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/users/"+recipient+"/recommendations/received", s.token(sender), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestCollectionRoutes(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	friend := s.createUser("friend")
+	stranger := s.createUser("stranger")
+	property := s.createProperty(stranger, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", Price: 100})
+
+	base := "/api/users/" + owner + "/collections"
+
+	t.Run("requires authentication", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodGet, base, "", nil).Code)
+	})
+
+	t.Run("only the owner can list or create", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, s.request(http.MethodGet, base, s.token(friend), nil).Code)
+		assert.Equal(t, http.StatusForbidden, s.request(http.MethodPost, base, s.token(friend), gin.H{"name": "Mine"}).Code)
+	})
+
+	w := s.request(http.MethodPost, base, s.token(owner), gin.H{"name": "Weekend homes"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	collectionID := decode(t, w)["collection"].(map[string]interface{})["id"].(string)
+	collectionPath := base + "/" + collectionID
+	itemPath := collectionPath + "/items/" + property
+
+	t.Run("list includes the default collection", func(t *testing.T) {
+		w := s.request(http.MethodGet, base, s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 2, decode(t, w)["count"])
+	})
+
+	t.Run("duplicate names conflict", func(t *testing.T) {
+		w := s.request(http.MethodPost, base, s.token(owner), gin.H{"name": "Weekend homes"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		w := s.request(http.MethodPut, collectionPath, s.token(owner), gin.H{"name": "Getaways"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = s.request(http.MethodPut, collectionPath, s.token(friend), gin.H{"name": "Mine now"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("non-members cannot see the collection", func(t *testing.T) {
+		w := s.request(http.MethodGet, collectionPath, s.token(stranger), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = s.request(http.MethodPost, collectionPath+"/items", s.token(stranger), gin.H{"propertyId": property})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("items", func(t *testing.T) {
+		w := s.request(http.MethodPost, collectionPath+"/items", s.token(owner), gin.H{"propertyId": property, "note": "near the beach"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = s.request(http.MethodPost, collectionPath+"/items", s.token(owner), gin.H{"propertyId": property})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = s.request(http.MethodPost, collectionPath+"/items", s.token(owner), gin.H{"propertyId": primitive.NewObjectID().Hex()})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = s.request(http.MethodPut, itemPath, s.token(owner), gin.H{"note": "right on the beach"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodGet, collectionPath, s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		body := decode(t, w)
+		assert.EqualValues(t, 1, body["count"])
+		item := body["items"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "right on the beach", item["note"])
+	})
+
+	t.Run("collaborators", func(t *testing.T) {
+		w := s.request(http.MethodPost, collectionPath+"/collaborators", s.token(friend), gin.H{"userId": friend})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = s.request(http.MethodPost, collectionPath+"/collaborators", s.token(owner), gin.H{"userId": owner})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = s.request(http.MethodPost, collectionPath+"/collaborators", s.token(owner), gin.H{"userId": friend})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// Collaborators see the collection without the owner's notes
+		w = s.request(http.MethodGet, collectionPath, s.token(friend), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		item := decode(t, w)["items"].([]interface{})[0].(map[string]interface{})
+		assert.Empty(t, item["note"])
+
+		w = s.request(http.MethodGet, "/api/me/shared-collections", s.token(friend), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		body := decode(t, w)
+		assert.EqualValues(t, 1, body["count"])
+		shared := body["collections"].([]interface{})[0].(map[string]interface{})
+		assert.Empty(t, shared["shareToken"])
+		for _, item := range shared["items"].([]interface{}) {
+			assert.Empty(t, item.(map[string]interface{})["note"])
+		}
+
+		// Only the owner edits notes, even for collaborators
+		w = s.request(http.MethodPut, itemPath, s.token(friend), gin.H{"note": "mine"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("comments and reactions", func(t *testing.T) {
+		w := s.request(http.MethodPost, itemPath+"/comments", s.token(friend), gin.H{"text": "Love it"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		commentID := decode(t, w)["comment"].(map[string]interface{})["id"].(string)
+
+		w = s.request(http.MethodPost, itemPath+"/comments", s.token(friend), gin.H{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = s.request(http.MethodPost, itemPath+"/comments", s.token(stranger), gin.H{"text": "Hi"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = s.request(http.MethodPut, itemPath+"/reaction", s.token(friend), gin.H{"value": "up"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodPut, itemPath+"/reaction", s.token(friend), gin.H{"value": "sideways"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = s.request(http.MethodDelete, itemPath+"/comments/"+commentID, s.token(stranger), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = s.request(http.MethodDelete, itemPath+"/comments/"+commentID, s.token(friend), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodDelete, itemPath+"/comments/"+commentID, s.token(friend), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("share link", func(t *testing.T) {
+		w := s.request(http.MethodPost, collectionPath+"/share-link", s.token(friend), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = s.request(http.MethodPost, collectionPath+"/share-link", s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		sharedPath := decode(t, w)["path"].(string)
+
+		// The shared view needs no token and hides notes
+		w = s.request(http.MethodGet, sharedPath, "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		body := decode(t, w)
+		assert.EqualValues(t, 1, body["count"])
+		item := body["items"].([]interface{})[0].(map[string]interface{})
+		assert.NotContains(t, item, "note")
+		assert.EqualValues(t, 1, item["thumbsUp"])
+
+		w = s.request(http.MethodDelete, collectionPath+"/share-link", s.token(owner), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodGet, sharedPath, "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("remove item and collaborator", func(t *testing.T) {
+		w := s.request(http.MethodDelete, itemPath, s.token(friend), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodDelete, itemPath, s.token(friend), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// Collaborators may leave on their own
+		w = s.request(http.MethodDelete, collectionPath+"/collaborators/"+friend, s.token(friend), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodGet, collectionPath, s.token(friend), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("someone else's draft cannot be saved or seen", func(t *testing.T) {
+		draft := s.createProperty(stranger, models.Property{Title: "Plot", Type: "Villa", City: "Pune", Price: 100, Status: models.PropertyDraft})
+		favoritesPath := "/api/users/" + owner + "/favorites"
+
+		w := s.request(http.MethodPost, favoritesPath, s.token(owner), gin.H{"propertyId": draft})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = s.request(http.MethodPost, collectionPath+"/items", s.token(owner), gin.H{"propertyId": draft})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// Its owner may still save it
+		w = s.request(http.MethodPost, "/api/users/"+stranger+"/favorites", s.token(stranger), gin.H{"propertyId": draft})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// A draft saved before that was refused is left out of the responses
+		legacy := s.createProperty(stranger, models.Property{Title: "Barn", Type: "Villa", City: "Pune", Price: 100})
+		require.Equal(t, http.StatusOK, s.request(http.MethodPost, favoritesPath, s.token(owner), gin.H{"propertyId": legacy}).Code)
+		require.Equal(t, http.StatusOK, s.request(http.MethodPost, collectionPath+"/items", s.token(owner), gin.H{"propertyId": legacy}).Code)
+		_, err := s.app.Properties.Update(context.Background(), stranger, legacy, database.AnyVersion, database.PropertyUpdate{
+			Set: map[string]interface{}{"status": models.PropertyDraft},
+		})
+		require.NoError(t, err)
+
+		w = s.request(http.MethodGet, favoritesPath, s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), legacy)
+
+		w = s.request(http.MethodGet, collectionPath, s.token(owner), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), legacy)
+		assert.EqualValues(t, len(decode(t, w)["items"].([]interface{})), decode(t, w)["count"])
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := s.request(http.MethodDelete, collectionPath, s.token(friend), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = s.request(http.MethodDelete, collectionPath, s.token(owner), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodGet, collectionPath, s.token(owner), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMeRoutes(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createUser("me")
+	other := s.createUser("other")
+
+	t.Run("requires authentication", func(t *testing.T) {
+		for _, path := range []string{"/api/me/properties", "/api/me/recommended", "/api/me/shared-collections", "/api/me/notifications", "/api/me/notification-preferences"} {
+			assert.Equal(t, http.StatusUnauthorized, s.request(http.MethodGet, path, "", nil).Code, path)
+		}
+	})
+
+	t.Run("recommended", func(t *testing.T) {
+		seed := s.createProperty(other, models.Property{Title: "Seed", Type: "Apartment", City: "Pune", Price: 5000000, Rating: 4})
+		match := s.createProperty(other, models.Property{Title: "Match", Type: "Apartment", City: "Pune", Price: 5200000, Rating: 5})
+		require.NoError(t, s.app.Favorites.AddFavorite(context.Background(), user, seed))
+
+		w := s.request(http.MethodGet, "/api/me/recommended", s.token(user), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		body := decode(t, w)
+		require.EqualValues(t, 1, body["count"])
+		recommendation := body["recommendations"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, match, recommendation["property"].(map[string]interface{})["id"])
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		userObjID, _ := primitive.ObjectIDFromHex(user)
+		notification := &models.Notification{UserID: userObjID, Type: "price_drop", Title: "Price drop"}
+		require.NoError(t, s.app.Notifications.Create(context.Background(), notification))
+
+		w := s.request(http.MethodGet, "/api/me/notifications?unread=true", s.token(user), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 1, decode(t, w)["count"])
+
+		// Another user's inbox does not expose it, nor can they mark it read
+		w = s.request(http.MethodGet, "/api/me/notifications", s.token(other), nil)
+		assert.EqualValues(t, 0, decode(t, w)["count"])
+		w = s.request(http.MethodPost, "/api/me/notifications/"+notification.ID.Hex()+"/read", s.token(other), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = s.request(http.MethodPost, "/api/me/notifications/"+notification.ID.Hex()+"/read", s.token(user), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodGet, "/api/me/notifications?unread=true", s.token(user), nil)
+		assert.EqualValues(t, 0, decode(t, w)["count"])
+
+		w = s.request(http.MethodPost, "/api/me/notifications/not-an-id/read", s.token(user), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("notification preferences", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/me/notification-preferences", s.token(user), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = s.request(http.MethodPut, "/api/me/notification-preferences", s.token(user), gin.H{"channels": []string{"webhook"}, "webhookUrl": "http://insecure.example.com"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		for _, webhookURL := range []string{"https://127.0.0.1/hook", "https://169.254.169.254/latest", "https://10.0.0.5", "https://[::1]:8443", "https://localhost/hook"} {
+			w = s.request(http.MethodPut, "/api/me/notification-preferences", s.token(user), gin.H{"channels": []string{"webhook"}, "webhookUrl": webhookURL})
+			assert.Equal(t, http.StatusBadRequest, w.Code, webhookURL)
+		}
+
+		w = s.request(http.MethodPut, "/api/me/notification-preferences", s.token(user), gin.H{"priceDrop": true, "channels": []string{models.ChannelInApp}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = s.request(http.MethodGet, "/api/me/notification-preferences", s.token(user), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		preferences := decode(t, w)["preferences"].(map[string]interface{})
+		assert.Equal(t, true, preferences["priceDrop"])
+		assert.Equal(t, false, preferences["availability"])
+	})
+}
+
+func TestProblemResponses(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner")
+	other := s.createUser("other")
+	property := s.createProperty(owner, models.Property{Title: "Flat", Type: "Apartment", City: "Pune", Price: 100})
+
+	t.Run("errors are problem documents with stable codes", func(t *testing.T) {
+		w := s.requestWith(http.MethodDelete, "/api/properties/"+property, s.token(other), anyVersion, nil)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+
+		problem := decode(t, w)
+		assert.Equal(t, "property_not_owned", problem["code"])
+		assert.Equal(t, "urn:hypergro:problem:property_not_owned", problem["type"])
+		assert.EqualValues(t, http.StatusForbidden, problem["status"])
+		assert.Equal(t, "/api/properties/"+property, problem["instance"])
+		assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), problem["requestId"])
+	})
+
+	t.Run("invalid tokens stop the request", func(t *testing.T) {
+		w := s.request(http.MethodPost, "/api/properties", "not-a-token", gin.H{"title": "x"})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "invalid_token", decode(t, w)["code"])
+	})
+
+	t.Run("invalid IDs are bad requests", func(t *testing.T) {
+		w := s.requestWith(http.MethodPut, "/api/properties/not-an-id", s.token(owner), anyVersion, gin.H{"price": 1})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_property_id", decode(t, w)["code"])
+	})
+
+	t.Run("unknown routes", func(t *testing.T) {
+		w := s.request(http.MethodGet, "/api/nowhere", "", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "route_not_found", decode(t, w)["code"])
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("Metrics Owner")
+	id := s.createProperty(owner, models.Property{Title: "Counted", Type: "Villa", Price: 100, State: "Kerala", City: "Kochi"})
+
+	route := "/api/properties/:id"
+	before := metrics.HTTPRequests.Value(http.MethodGet, route, "200")
+	s.request(http.MethodGet, "/api/properties/"+id, "", nil)
+	s.request(http.MethodGet, "/no/such/path", "", nil)
+	assert.Equal(t, before+1, metrics.HTTPRequests.Value(http.MethodGet, route, "200"))
+
+	w := httptest.NewRecorder()
+	newAdminServer(":0").Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/api/properties/:id",status="200"}`)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="unmatched",status="404"}`)
+}
+
+func TestRequestIDAndLogging(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createUser("logged")
+
+	var out bytes.Buffer
+	previous := slog.Default()
+	logging.Setup(&out, slog.LevelInfo)
+	defer slog.SetDefault(previous)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/notifications", nil)
+	req.Header.Set("Authorization", "Bearer "+s.token(user))
+	req.Header.Set(middleware.RequestIDHeader, "client-supplied-1")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "client-supplied-1", w.Header().Get(middleware.RequestIDHeader))
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "client-supplied-1", line[logging.RequestIDKey])
+	assert.Equal(t, user, line[logging.UserIDKey])
+	assert.Equal(t, "/api/me/notifications", line["route"])
+
+	// IDs that could forge log lines are replaced by a generated one
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set(middleware.RequestIDHeader, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	assert.Regexp(t, "^[0-9a-f]{32}$", w.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestRateLimit(t *testing.T) {
+	s := newTestServer(t, func(app *App) {
+		app.RateLimiter = middleware.NewMemoryRateLimitStore()
+		app.RateLimits = map[string]middleware.RateLimitPolicy{
+			"auth":          {Name: "auth", Limit: 2, Window: time.Minute, Identity: middleware.IdentityIP},
+			"authenticated": {Name: "authenticated", Limit: 1, Window: time.Minute, Identity: middleware.IdentityUser},
+		}
+	})
+	require.NoError(t, s.router.SetTrustedProxies([]string{"192.0.2.1"}))
+
+	login := func(clientIP string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{}`))
+		req.RemoteAddr = "192.0.2.1:4000"
+		req.Header.Set("X-Forwarded-For", clientIP)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("auth is limited per client IP", func(t *testing.T) {
+		w := login("203.0.113.7")
+		assert.NotEqual(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+		login("203.0.113.7")
+		before := metrics.RateLimited.Value("auth")
+		w = login("203.0.113.7")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Equal(t, before+1, metrics.RateLimited.Value("auth"))
+
+		// Another client behind the same proxy has its own budget
+		assert.NotEqual(t, http.StatusTooManyRequests, login("203.0.113.8").Code)
+	})
+
+	t.Run("authenticated routes are limited per user", func(t *testing.T) {
+		alice, bob := s.createUser("limited-alice"), s.createUser("limited-bob")
+
+		assert.Equal(t, http.StatusOK, s.request(http.MethodGet, "/api/me/notifications", s.token(alice), nil).Code)
+		assert.Equal(t, http.StatusTooManyRequests, s.request(http.MethodGet, "/api/me/notifications", s.token(alice), nil).Code)
+		assert.Equal(t, http.StatusOK, s.request(http.MethodGet, "/api/me/notifications", s.token(bob), nil).Code)
+	})
+
+	t.Run("groups without a policy are not limited", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			w := s.request(http.MethodGet, "/api/properties", "", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		}
+	})
+}
@@ -1,30 +1,204 @@
 package main
 
 import (
+	"Praiseson6065/Hypergro-assign/config"
+	"Praiseson6065/Hypergro-assign/metrics"
 	"Praiseson6065/Hypergro-assign/middleware"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func Server() error {
-	env := viper.GetString("ENVIRONMENT")
-	port := viper.GetString(env + ".server.port")
-	r := gin.New()
-	r.Use(middleware.CORS())
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+const (
+	defaultShutdownTimeout = 10 * time.Second
+	readHeaderTimeout      = 10 * time.Second
+)
+
+// Serve handles `serve`, starting the HTTP server and background workers. On SIGINT or
+// SIGTERM readiness starts failing, in-flight requests are drained and the workers,
+// Redis and MongoDB are shut down in that order.
+func Serve(args []string) error {
+	flags, profile := newFlagSet("serve")
+	port := flags.String("port", "", "address to listen on, overriding server.port")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*profile)
+	if err != nil {
+		return err
+	}
+	if *port == "" {
+		*port = cfg.Server.Port
+	}
+
+	gin.SetMode(cfg.Server.Mode)
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	slog.Info("Loaded configuration", "config", cfg)
+
+	if err := ensureSchema(cfg.Migrations, app.DB); err != nil {
+		return err
+	}
+
+	r := newEngine()
+	// X-Forwarded-For from these proxies, i.e. nginx, gives the client IP used by rate limits and logs
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return err
+	}
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(untracedPaths)))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.CORS(middleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           time.Duration(cfg.CORS.MaxAge) * time.Second,
+	}))
+	r.Use(middleware.Errors())
+	r.Use(middleware.Recovery())
+	r.NoRoute(middleware.NoRoute())
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"Hello": "World",
 		})
 	})
-	AuthRouter(r)
-	ApiRouter(r) 
-	err := r.Run(port)
-	if err != nil {
+	HealthRouter(r, app)
+	AuthRouter(r, app)
+	ApiRouter(r, app)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs := StartJobs(jobsCtx, cfg.Jobs, app)
+	dispatcher := StartNotifications(cfg.Notifications, app)
+	app.Views.Start()
+
+	srv := &http.Server{
+		Addr:              *port,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	// Metrics are served on their own port, which nginx does not proxy
+	var admin *http.Server
+	if addr := cfg.Server.AdminPort; addr != "" {
+		admin = newAdminServer(addr)
+		go func() {
+			slog.Info("Admin server is starting", "addr", addr)
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Admin server failed", "error", err)
+			}
+		}()
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server is starting", "addr", *port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		// The listener failed before any signal; workers still need stopping
+	case <-signalCtx.Done():
+		slog.Info("Shutdown signal received, draining")
+	}
+	// A second signal now terminates the process immediately
+	stopSignals()
+
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	if err == nil {
+		app.Probe.SetShuttingDown()
+		// Give load balancers time to see readiness fail before connections are refused
+		if delay := time.Duration(cfg.Server.ShutdownDelay) * time.Second; delay > 0 {
+			time.Sleep(delay)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := srv.Shutdown(ctx); shutdownErr != nil {
+		slog.Error("HTTP server did not drain in time", "error", shutdownErr)
+	} else {
+		slog.Info("HTTP server stopped")
+	}
+	// The admin server outlives the drain so it can be scraped meanwhile
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			slog.Error("Admin server did not stop in time", "error", err)
+		}
+	}
+
+	// Requests can no longer enqueue work, so the workers are stopped next
+	stopJobs()
+	if !waitUntil(ctx, jobs.Wait) {
+		slog.Error("Background jobs did not stop in time")
+	}
+	if !waitUntil(ctx, dispatcher.Stop) {
+		slog.Error("Notification dispatcher did not drain in time")
+	}
+	if !waitUntil(ctx, app.Views.Stop) {
+		slog.Error("View recorder did not drain in time")
+	}
+
+	// app.Close, deferred above, closes Redis and then MongoDB
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
+
+// untracedPaths leaves out the probes, which would otherwise dominate the traces
+func untracedPaths(r *http.Request) bool {
+	return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
+// newAdminServer serves the operational endpoints that must not be public
+func newAdminServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
+
+// waitUntil runs fn and reports whether it returned before ctx was done
+func waitUntil(ctx context.Context, fn func()) bool {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,5 +1,422 @@
+// Package config loads the settings of one profile of config.yaml into a typed
+// Config. String values may reference environment variables as ${VAR} or
+// ${VAR:-default}, and any setting can be overridden with an environment variable
+// named HYPERGRO_ followed by its key path, e.g. HYPERGRO_SERVER_PORT.
 package config
 
-func init() {
-	viperConfig()
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix starts the name of every environment override
+const EnvPrefix = "HYPERGRO_"
+
+// Config holds every setting of one profile. Durations are whole numbers in the
+// unit named on each field, as written in config.yaml.
+type Config struct {
+	Profile       string              `mapstructure:"-"`
+	JWT           JWTConfig           `mapstructure:"jwt"`
+	Server        ServerConfig        `mapstructure:"server"`
+	MongoDB       MongoConfig         `mapstructure:"mongodb"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	Tracing       TracingConfig       `mapstructure:"tracing"`
+	Migrations    MigrationsConfig    `mapstructure:"migrations"`
+	Jobs          JobsConfig          `mapstructure:"jobs"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Views         ViewsConfig         `mapstructure:"views"`
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
+	CORS          CORSConfig          `mapstructure:"cors"`
+
+	// unresolved maps keys to the environment variables they referenced that were not set
+	unresolved map[string][]string
+}
+
+type JWTConfig struct {
+	Secret string `mapstructure:"secret"`
+	// Expire is the token lifetime in minutes
+	Expire int `mapstructure:"expire"`
+}
+
+type ServerConfig struct {
+	Port string `mapstructure:"port"`
+	// Mode is the gin mode: debug, release or test
+	Mode             string `mapstructure:"mode"`
+	ReadinessTimeout int    `mapstructure:"readiness_timeout"` // seconds
+	// AdminPort serves /metrics; empty disables it
+	AdminPort       string `mapstructure:"admin_port"`
+	ShutdownDelay   int    `mapstructure:"shutdown_delay"`   // seconds
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // seconds
+	// TrustedProxies are the IPs or CIDR ranges whose X-Forwarded-For is believed
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+type MongoConfig struct {
+	URI      string        `mapstructure:"uri"`
+	Database string        `mapstructure:"database"`
+	Timeout  int           `mapstructure:"timeout"` // seconds
+	Timeouts MongoTimeouts `mapstructure:"timeouts"`
+}
+
+// MongoTimeouts are per-operation limits in seconds
+type MongoTimeouts struct {
+	Read  int `mapstructure:"read"`
+	Write int `mapstructure:"write"`
+	Batch int `mapstructure:"batch"`
+}
+
+type RedisConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+	Timeout  int    `mapstructure:"timeout"` // seconds
+}
+
+type CacheConfig struct {
+	ServeStale   bool   `mapstructure:"serve_stale"`
+	Mode         string `mapstructure:"mode"`
+	L1MaxEntries int    `mapstructure:"l1_max_entries"`
+	L1TTL        int    `mapstructure:"l1_ttl"` // seconds
+}
+
+type LoggingConfig struct {
+	Level string `mapstructure:"level"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp, stdout or file
+	Exporter    string  `mapstructure:"exporter"`
+	ServiceName string  `mapstructure:"service_name"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	File        string  `mapstructure:"file"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+type MigrationsConfig struct {
+	AutoApply bool `mapstructure:"auto_apply"`
+}
+
+// JobsConfig schedules the background jobs; an interval of zero disables a job
+type JobsConfig struct {
+	RecommendationsInterval int `mapstructure:"recommendations_interval"` // minutes
+	CooccurrenceInterval    int `mapstructure:"cooccurrence_interval"`    // minutes
+	CooccurrenceMinSupport  int `mapstructure:"cooccurrence_min_support"`
+	CooccurrenceTopN        int `mapstructure:"cooccurrence_top_n"`
+	ExpiryInterval          int `mapstructure:"expiry_interval"` // minutes
+	// ListingTTL is how long an active listing stays up without being renewed
+	ListingTTL int `mapstructure:"listing_ttl"` // days
+}
+
+type NotificationsConfig struct {
+	Workers        int        `mapstructure:"workers"`
+	QueueSize      int        `mapstructure:"queue_size"`
+	WebhookTimeout int        `mapstructure:"webhook_timeout"` // seconds
+	SMTP           SMTPConfig `mapstructure:"smtp"`
+}
+
+// ViewsConfig sizes the background writer of the listings users open
+type ViewsConfig struct {
+	Workers   int `mapstructure:"workers"`
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// SMTPConfig enables email notifications when Host is set
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	Timeout  int    `mapstructure:"timeout"` // seconds
+}
+
+// RateLimitConfig holds a policy per route group. Requests are counted in Redis
+// when it is configured, so the limits hold across replicas.
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Auth covers /auth/signup and /auth/login
+	Auth RateLimitPolicyConfig `mapstructure:"auth"`
+	// Public covers the endpoints that need no token
+	Public RateLimitPolicyConfig `mapstructure:"public"`
+	// Authenticated covers the endpoints that require a token
+	Authenticated RateLimitPolicyConfig `mapstructure:"authenticated"`
+	// Recommendations and Import add tighter limits to POST /api/recommendations
+	// and the CSV import
+	Recommendations RateLimitPolicyConfig `mapstructure:"recommendations"`
+	Import          RateLimitPolicyConfig `mapstructure:"import"`
+}
+
+// RateLimitPolicyConfig allows Limit requests per Window seconds for each identity:
+// ip or user. A Limit of zero disables the policy.
+type RateLimitPolicyConfig struct {
+	Limit    int    `mapstructure:"limit"`
+	Window   int    `mapstructure:"window"` // seconds
+	Identity string `mapstructure:"identity"`
+}
+
+// Policies returns the policies by name
+func (r RateLimitConfig) Policies() map[string]RateLimitPolicyConfig {
+	return map[string]RateLimitPolicyConfig{
+		"auth":            r.Auth,
+		"public":          r.Public,
+		"authenticated":   r.Authenticated,
+		"recommendations": r.Recommendations,
+		"import":          r.Import,
+	}
+}
+
+// CORSConfig says which browser origins may call the API. An origin may start its
+// host with "*." to allow every subdomain; no origins disables CORS.
+type CORSConfig struct {
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	MaxAge           int      `mapstructure:"max_age"` // seconds
+}
+
+// defaults lists every setting, so each one can be overridden from the environment
+// even when config.yaml leaves it out
+var defaults = map[string]interface{}{
+	"jwt.secret": "",
+	"jwt.expire": 60,
+
+	"server.port":              ":8000",
+	"server.mode":              "release",
+	"server.readiness_timeout": 2,
+	"server.admin_port":        "",
+	"server.shutdown_delay":    0,
+	"server.shutdown_timeout":  10,
+	"server.trusted_proxies":   []string{"127.0.0.1", "::1"},
+
+	"mongodb.uri":            "",
+	"mongodb.database":       "",
+	"mongodb.timeout":        10,
+	"mongodb.timeouts.read":  5,
+	"mongodb.timeouts.write": 10,
+	"mongodb.timeouts.batch": 600,
+
+	"redis.addr":     "",
+	"redis.password": "",
+	"redis.db":       0,
+	"redis.timeout":  5,
+
+	"cache.serve_stale":    false,
+	"cache.mode":           "tiered",
+	"cache.l1_max_entries": 10000,
+	"cache.l1_ttl":         30,
+
+	"logging.level": "info",
+
+	"tracing.exporter":     "none",
+	"tracing.service_name": "hypergro-backend",
+	"tracing.endpoint":     "",
+	"tracing.insecure":     false,
+	"tracing.file":         "",
+	"tracing.sample_ratio": 1.0,
+
+	"migrations.auto_apply": false,
+
+	"jobs.recommendations_interval": 0,
+	"jobs.cooccurrence_interval":    0,
+	"jobs.cooccurrence_min_support": 5,
+	"jobs.cooccurrence_top_n":       10,
+	"jobs.expiry_interval":          0,
+	"jobs.listing_ttl":              90,
+
+	"notifications.workers":         1,
+	"notifications.queue_size":      100,
+	"notifications.webhook_timeout": 5,
+	"notifications.smtp.host":       "",
+	"notifications.smtp.port":       587,
+	"notifications.smtp.username":   "",
+	"notifications.smtp.password":   "",
+	"notifications.smtp.from":       "",
+	"notifications.smtp.timeout":    10,
+
+	"views.workers":    2,
+	"views.queue_size": 1000,
+
+	"rate_limit.enabled":                  true,
+	"rate_limit.auth.limit":               10,
+	"rate_limit.auth.window":              60,
+	"rate_limit.auth.identity":            "ip",
+	"rate_limit.public.limit":             300,
+	"rate_limit.public.window":            60,
+	"rate_limit.public.identity":          "ip",
+	"rate_limit.authenticated.limit":      120,
+	"rate_limit.authenticated.window":     60,
+	"rate_limit.authenticated.identity":   "user",
+	"rate_limit.recommendations.limit":    20,
+	"rate_limit.recommendations.window":   60,
+	"rate_limit.recommendations.identity": "user",
+	"rate_limit.import.limit":             5,
+	"rate_limit.import.window":            3600,
+	"rate_limit.import.identity":          "user",
+
+	"cors.allowed_origins":   []string{},
+	"cors.allowed_methods":   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+	"cors.allowed_headers":   []string{"Accept", "Authorization", "Content-Type", "Cache-Control", "X-Requested-With", "X-Request-ID", "If-Match", "If-None-Match"},
+	"cors.exposed_headers":   []string{"X-Request-ID", "ETag", "Retry-After", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+	"cors.allow_credentials": false,
+	"cors.max_age":           600,
+}
+
+// envAliases are environment variables honoured for compatibility with existing deployments
+var envAliases = map[string]string{
+	"MONGO_URI": "mongodb.uri",
+}
+
+// sharedSections may be set at the top level of config.yaml for every profile
+var sharedSections = []string{"jwt"}
+
+// File is the configuration file read by Load, looked up in the working directory
+var File = "config.yaml"
+
+// DefaultProfile is the profile used when none is given: the ENVIRONMENT variable,
+// else the ENVIRONMENT key of the config file, else development
+func DefaultProfile() string {
+	if profile := os.Getenv("ENVIRONMENT"); profile != "" {
+		return profile
+	}
+
+	v, err := readFile()
+	if err == nil {
+		if profile := v.GetString("environment"); profile != "" {
+			return profile
+		}
+	}
+	return "development"
+}
+
+// Load reads and validates a profile. The error lists every problem found.
+func Load(profile string) (*Config, error) {
+	cfg, err := Read(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Read reads a profile without validating it, for commands that need only part of it
+func Read(profile string) (*Config, error) {
+	file, err := readFile()
+	if err != nil {
+		return nil, err
+	}
+	if file.ConfigFileUsed() != "" && !file.IsSet(profile) {
+		return nil, fmt.Errorf("profile %q is not defined in %s", profile, File)
+	}
+
+	merged := viper.New()
+	unresolved := make(map[string][]string)
+
+	for key, fallback := range defaults {
+		value, found := lookupKey(file, profile, key)
+		if !found {
+			value = fallback
+		}
+
+		if s, ok := value.(string); ok {
+			var missing []string
+			value, missing = expand(s)
+			if len(missing) > 0 {
+				unresolved[key] = missing
+			}
+		}
+
+		if override, ok := envOverride(key); ok {
+			value = override
+			delete(unresolved, key)
+		}
+
+		merged.Set(key, value)
+	}
+
+	cfg := &Config{Profile: profile, unresolved: unresolved}
+	if err := merged.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("reading profile %q: %w", profile, err)
+	}
+	return cfg, nil
+}
+
+// readFile reads the config file, which may be absent when everything comes from the environment
+func readFile() (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(File)
+	v.SetConfigType("yaml")
+
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return viper.New(), nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", File, err)
+	}
+	return v, nil
+}
+
+// lookupKey finds a key in the profile, falling back to the top level for shared sections
+func lookupKey(file *viper.Viper, profile string, key string) (interface{}, bool) {
+	if file.IsSet(profile + "." + key) {
+		return file.Get(profile + "." + key), true
+	}
+	for _, section := range sharedSections {
+		if strings.HasPrefix(key, section+".") && file.IsSet(key) {
+			return file.Get(key), true
+		}
+	}
+	return nil, false
+}
+
+// EnvName is the environment variable that overrides a key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func envOverride(key string) (string, bool) {
+	if value, ok := os.LookupEnv(EnvName(key)); ok {
+		return value, true
+	}
+	for alias, aliasKey := range envAliases {
+		if aliasKey == key {
+			if value, ok := os.LookupEnv(alias); ok && value != "" {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expand replaces ${VAR} and ${VAR:-default} references. It returns the names of
+// variables that were unset and had no default, which expand to nothing.
+func expand(s string) (string, []string) {
+	var missing []string
+	expanded := envReference.ReplaceAllStringFunc(s, func(reference string) string {
+		match := envReference.FindStringSubmatch(reference)
+		name, hasDefault, fallback := match[1], match[2] != "", match[3]
+
+		if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+			return value
+		}
+		if hasDefault {
+			return fallback
+		}
+		missing = append(missing, name)
+		return ""
+	})
+	return expanded, missing
 }
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every problem found in a profile
+type ValidationError struct {
+	Profile  string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration for profile %q:\n  - %s", e.Profile, strings.Join(e.Problems, "\n  - "))
+}
+
+type validator struct {
+	cfg      *Config
+	problems []string
+}
+
+func (v *validator) addf(key string, format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf("%s (%s): %s", key, EnvName(key), fmt.Sprintf(format, args...)))
+}
+
+// required reports an empty value, naming the environment variables it was meant to come from
+func (v *validator) required(key string, value string) bool {
+	if value != "" {
+		return true
+	}
+	if missing := v.cfg.unresolved[key]; len(missing) > 0 {
+		v.addf(key, "is required, but environment variable %s is not set", strings.Join(missing, ", "))
+	} else {
+		v.addf(key, "is required")
+	}
+	return false
+}
+
+func (v *validator) address(key string, value string) {
+	if !v.required(key, value) {
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		v.addf(key, "%q is not a host:port address", value)
+	}
+}
+
+func (v *validator) oneOf(key string, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) positive(key string, value int) {
+	if value <= 0 {
+		v.addf(key, "must be positive, got %d", value)
+	}
+}
+
+func (v *validator) notNegative(key string, value int) {
+	if value < 0 {
+		v.addf(key, "must not be negative, got %d", value)
+	}
+}
+
+// Validate checks the whole profile
+func (c *Config) Validate() error {
+	v := &validator{cfg: c}
+
+	v.required("jwt.secret", c.JWT.Secret)
+	v.positive("jwt.expire", c.JWT.Expire)
+
+	v.address("server.port", c.Server.Port)
+	v.oneOf("server.mode", c.Server.Mode, "debug", "release", "test")
+	v.positive("server.readiness_timeout", c.Server.ReadinessTimeout)
+	if c.Server.AdminPort != "" {
+		v.address("server.admin_port", c.Server.AdminPort)
+		if c.Server.AdminPort == c.Server.Port {
+			v.addf("server.admin_port", "must differ from server.port")
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				v.addf("server.trusted_proxies", "%q is not an IP address or CIDR range", proxy)
+			}
+		}
+	}
+	v.notNegative("server.shutdown_delay", c.Server.ShutdownDelay)
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+
+	c.MongoDB.validate(v)
+
+	v.oneOf("cache.mode", c.Cache.Mode, "tiered", "redis", "memory")
+	if c.Cache.Mode != "memory" {
+		v.address("redis.addr", c.Redis.Addr)
+	}
+	v.notNegative("redis.db", c.Redis.DB)
+	v.positive("redis.timeout", c.Redis.Timeout)
+	v.notNegative("cache.l1_max_entries", c.Cache.L1MaxEntries)
+	v.notNegative("cache.l1_ttl", c.Cache.L1TTL)
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		v.addf("logging.level", "%q is not one of debug, info, warn, error", c.Logging.Level)
+	}
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "otlp", "stdout", "file")
+	switch c.Tracing.Exporter {
+	case "otlp":
+		v.address("tracing.endpoint", c.Tracing.Endpoint)
+	case "file":
+		v.required("tracing.file", c.Tracing.File)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	v.notNegative("jobs.recommendations_interval", c.Jobs.RecommendationsInterval)
+	v.notNegative("jobs.cooccurrence_interval", c.Jobs.CooccurrenceInterval)
+	if c.Jobs.CooccurrenceInterval > 0 {
+		v.positive("jobs.cooccurrence_min_support", c.Jobs.CooccurrenceMinSupport)
+		v.positive("jobs.cooccurrence_top_n", c.Jobs.CooccurrenceTopN)
+	}
+	v.notNegative("jobs.expiry_interval", c.Jobs.ExpiryInterval)
+	if c.Jobs.ExpiryInterval > 0 {
+		v.positive("jobs.listing_ttl", c.Jobs.ListingTTL)
+	}
+
+	v.notNegative("notifications.workers", c.Notifications.Workers)
+	v.notNegative("notifications.queue_size", c.Notifications.QueueSize)
+	v.positive("notifications.webhook_timeout", c.Notifications.WebhookTimeout)
+	if smtp := c.Notifications.SMTP; smtp.Host != "" {
+		if smtp.Port <= 0 || smtp.Port > 65535 {
+			v.addf("notifications.smtp.port", "%d is not a valid port", smtp.Port)
+		}
+		v.required("notifications.smtp.from", smtp.From)
+		v.positive("notifications.smtp.timeout", smtp.Timeout)
+	}
+
+	v.notNegative("views.workers", c.Views.Workers)
+	v.notNegative("views.queue_size", c.Views.QueueSize)
+
+	c.CORS.validate(v)
+
+	if c.RateLimit.Enabled {
+		for name, policy := range c.RateLimit.Policies() {
+			key := "rate_limit." + name
+			v.notNegative(key+".limit", policy.Limit)
+			if policy.Limit > 0 {
+				v.positive(key+".window", policy.Window)
+				v.oneOf(key+".identity", policy.Identity, "ip", "user")
+			}
+		}
+	}
+
+	return v.result(c.Profile)
+}
+
+// ValidateMongoDB checks only the MongoDB settings, for commands that need nothing else
+func (c *Config) ValidateMongoDB() error {
+	v := &validator{cfg: c}
+	c.MongoDB.validate(v)
+	return v.result(c.Profile)
+}
+
+func (m MongoConfig) validate(v *validator) {
+	if v.required("mongodb.uri", m.URI) &&
+		!strings.HasPrefix(m.URI, "mongodb://") && !strings.HasPrefix(m.URI, "mongodb+srv://") {
+		v.addf("mongodb.uri", "must start with mongodb:// or mongodb+srv://")
+	}
+	v.required("mongodb.database", m.Database)
+	v.positive("mongodb.timeout", m.Timeout)
+	v.notNegative("mongodb.timeouts.read", m.Timeouts.Read)
+	v.notNegative("mongodb.timeouts.write", m.Timeouts.Write)
+	v.notNegative("mongodb.timeouts.batch", m.Timeouts.Batch)
+}
+
+func (c CORSConfig) validate(v *validator) {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				v.addf("cors.allowed_origins", `"*" cannot be combined with cors.allow_credentials, list the origins instead`)
+			}
+			continue
+		}
+
+		host := strings.Replace(origin, "://*.", "://", 1)
+		u, err := url.Parse(host)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(host, "*") {
+			v.addf("cors.allowed_origins", "%q is not an origin such as https://example.com or https://*.example.com", origin)
+		}
+	}
+
+	for _, method := range c.AllowedMethods {
+		if method == "" || method != strings.ToUpper(method) || strings.ContainsAny(method, " ,") {
+			v.addf("cors.allowed_methods", "%q is not an upper-case HTTP method", method)
+		}
+	}
+	v.notNegative("cors.max_age", c.MaxAge)
+}
+
+func (v *validator) result(profile string) error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	sort.Strings(v.problems)
+	return &ValidationError{Profile: profile, Problems: v.problems}
+}
+
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy with secrets masked, safe to log or print
+func (c Config) Redacted() Config {
+	c.unresolved = nil
+	if c.JWT.Secret != "" {
+		c.JWT.Secret = redacted
+	}
+	if c.Redis.Password != "" {
+		c.Redis.Password = redacted
+	}
+	if c.Notifications.SMTP.Password != "" {
+		c.Notifications.SMTP.Password = redacted
+	}
+	c.MongoDB.URI = redactURI(c.MongoDB.URI)
+	return c
+}
+
+// redactURI masks the password of a connection string
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		if uri == "" {
+			return ""
+		}
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// LogValue makes slog print the redacted settings
+func (c Config) LogValue() slog.Value {
+	r := c.Redacted()
+	return slog.GroupValue(
+		slog.String("profile", r.Profile),
+		slog.Any("server", r.Server),
+		slog.Any("mongodb", r.MongoDB),
+		slog.Any("redis", r.Redis),
+		slog.Any("cache", r.Cache),
+		slog.Any("logging", r.Logging),
+		slog.Any("tracing", r.Tracing),
+		slog.Any("migrations", r.Migrations),
+		slog.Any("jobs", r.Jobs),
+		slog.Any("notifications", r.Notifications),
+		slog.Any("rate_limit", r.RateLimit),
+		slog.Any("cors", r.CORS),
+		slog.Int("jwt_expire", r.JWT.Expire),
+		slog.String("jwt_secret", r.JWT.Secret),
+	)
+}
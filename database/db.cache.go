@@ -1,11 +1,17 @@
 package database
 
 import (
+	"Praiseson6065/Hypergro-assign/cache"
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -13,19 +19,44 @@ const (
 	MediumTerm = 1 * time.Hour
 	LongTerm   = 24 * time.Hour
 
-	PropertyKeyPrefix      = "property:"
+	// Versioned so entries cached before properties had a version or a status are never served
+	PropertyKeyPrefix      = "property:v3:"
 	PropertiesKeyPrefix    = "properties:"
 	UserFavoritesKeyPrefix = "user:favorites:"
 	UserKeyPrefix          = "user:"
+	UserRecommendedPrefix  = "user:recommended:"
+	AlsoSavedKeyPrefix     = "alsosaved:"
 )
 
-func GetFromCache(ctx *gin.Context, key string, result interface{}) (bool, error) {
-	val, err := RedisClient.Get(ctx, key).Result()
-	if err != nil {
+// CacheLayer adds JSON encoding, property list versioning and stampede protection on
+// top of a cache.Cache. Repositories share one CacheLayer so they can evict each
+// other's derived entries.
+type CacheLayer struct {
+	store      cache.Cache
+	serveStale bool
+	loadGroup  singleflight.Group
+}
+
+// NewCacheLayer wraps store. With serveStale, expired entries inside StaleWindow are
+// returned immediately while they are refreshed in the background.
+func NewCacheLayer(store cache.Cache, serveStale bool) *CacheLayer {
+	return &CacheLayer{store: store, serveStale: serveStale}
+}
+
+// Store returns the underlying cache
+func (c *CacheLayer) Store() cache.Cache {
+	return c.store
+}
+
+func (c *CacheLayer) GetFromCache(ctx context.Context, key string, result interface{}) (bool, error) {
+	val, found, err := c.store.Get(ctx, key)
+	if err != nil || !found {
+		recordCacheLookup(key, cacheMiss)
 		return false, nil
 	}
+	recordCacheLookup(key, cacheHit)
 
-	err = json.Unmarshal([]byte(val), result)
+	err = json.Unmarshal(val, result)
 	if err != nil {
 		return false, err
 	}
@@ -33,58 +64,256 @@ func GetFromCache(ctx *gin.Context, key string, result interface{}) (bool, error
 	return true, nil
 }
 
-func SetInCache(ctx *gin.Context, key string, value interface{}, expiry time.Duration) error {
+func (c *CacheLayer) SetInCache(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 
-	return RedisClient.Set(ctx, key, data, expiry).Err()
+	return c.store.Set(ctx, key, data, expiry)
+}
+
+func (c *CacheLayer) DeleteFromCache(ctx context.Context, key string) error {
+	return c.store.Delete(ctx, key)
 }
 
-func DeleteFromCache(ctx *gin.Context, key string) error {
-	return RedisClient.Del(ctx, key).Err()
+// Property lists are grouped into scopes: lists filtered on an exact city live in
+// "city:<name>", lists owned by a user in "owner:<id>", and every other list in "all".
+// Each scope has a version embedded in its cache keys and a tag tracking those
+// keys. Invalidating a scope bumps the version, so stale keys become unreachable in
+// O(1), and then evicts the tracked keys to reclaim memory. The keyspace is never scanned.
+const propertyScopeAll = "all"
+
+// PropertyListScope returns the scope of a property list query
+func PropertyListScope(filter models.PropertyFilter) string {
+	if filter.City != "" {
+		return "city:" + filter.City
+	}
+	return propertyScopeAll
 }
 
-func DeleteByPattern(ctx *gin.Context, pattern string) error {
-	keys, err := RedisClient.Keys(ctx, pattern).Result()
+// OwnerListScope returns the scope of a user's own property list
+func OwnerListScope(userID string) string {
+	return "owner:" + userID
+}
+
+// PropertyListKey builds the versioned cache key of a property list in a scope
+func (c *CacheLayer) PropertyListKey(ctx context.Context, scope string, suffix string) (string, error) {
+	version, err := c.store.Version(ctx, PropertiesKeyPrefix+scope)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if len(keys) > 0 {
-		return RedisClient.Del(ctx, keys...).Err()
+	return fmt.Sprintf("%s%s:v%d:%s", PropertiesKeyPrefix, scope, version, suffix), nil
+}
+
+// InvalidatePropertyScopes bumps the version of each scope and evicts the lists tracked under it
+func (c *CacheLayer) InvalidatePropertyScopes(ctx context.Context, scopes ...string) error {
+	for _, scope := range scopes {
+		if _, err := c.store.BumpVersion(ctx, PropertiesKeyPrefix+scope); err != nil {
+			return err
+		}
+
+		if err := c.store.InvalidateTags(ctx, PropertiesKeyPrefix+scope); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func ClearPropertyCache(ctx *gin.Context, propertyID string) {
-	key := PropertyKeyPrefix + propertyID
-	err := DeleteFromCache(ctx, key)
-	if err != nil {
-		log.Printf("Error clearing property cache for %s: %v", propertyID, err)
+// InvalidatePropertyLists evicts every cached list that could contain one of the given properties
+func (c *CacheLayer) InvalidatePropertyLists(ctx context.Context, properties ...models.Property) {
+	scopes := map[string]bool{propertyScopeAll: true}
+	for _, property := range properties {
+		if property.City != "" {
+			scopes["city:"+property.City] = true
+		}
+		if !property.CreatedBy.IsZero() {
+			scopes[OwnerListScope(property.CreatedBy.Hex())] = true
+		}
+	}
+
+	scopeList := make([]string, 0, len(scopes))
+	for scope := range scopes {
+		scopeList = append(scopeList, scope)
 	}
 
-	// Also clear any property lists that might contain this property
-	err = DeleteByPattern(ctx, PropertiesKeyPrefix+"*")
+	if err := c.InvalidatePropertyScopes(ctx, scopeList...); err != nil {
+		slog.WarnContext(ctx, "Error invalidating property list cache", "error", err)
+	}
+}
+
+func (c *CacheLayer) ClearPropertyCache(ctx context.Context, propertyID string) {
+	key := PropertyKeyPrefix + propertyID
+	err := c.DeleteFromCache(ctx, key)
 	if err != nil {
-		log.Printf("Error clearing properties list cache: %v", err)
+		slog.WarnContext(ctx, "Error clearing property cache", "property", propertyID, "error", err)
 	}
 }
 
-func ClearUserFavoritesCache(ctx *gin.Context, userID string) {
+func (c *CacheLayer) ClearUserFavoritesCache(ctx context.Context, userID string) {
 	key := UserFavoritesKeyPrefix + userID
-	err := DeleteFromCache(ctx, key)
+	err := c.DeleteFromCache(ctx, key)
 	if err != nil {
-		log.Printf("Error clearing user favorites cache for %s: %v", userID, err)
+		slog.WarnContext(ctx, "Error clearing user favorites cache", "user", userID, "error", err)
 	}
 }
 
-func ClearUserCache(ctx *gin.Context, userID string) {
+func (c *CacheLayer) ClearUserCache(ctx context.Context, userID string) {
 	key := UserKeyPrefix + userID
-	err := DeleteFromCache(ctx, key)
+	err := c.DeleteFromCache(ctx, key)
 	if err != nil {
-		log.Printf("Error clearing user cache for %s: %v", userID, err)
+		slog.WarnContext(ctx, "Error clearing user cache", "user", userID, "error", err)
+	}
+}
+
+// ClearPersonalisedRecommendationsCache drops a user's precomputed recommendations
+func (c *CacheLayer) ClearPersonalisedRecommendationsCache(ctx context.Context, userID string) {
+	err := c.DeleteFromCache(ctx, UserRecommendedPrefix+userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Error clearing personalised recommendations cache", "user", userID, "error", err)
+	}
+}
+
+// StaleWindow is how long past its freshness TTL an entry loaded through GetOrLoad
+// is kept, so it can be served while a single goroutine refreshes it
+const StaleWindow = ShortTerm
+
+const (
+	// earlyExpiryBeta tunes probabilistic early expiry; higher values refresh earlier
+	earlyExpiryBeta = 1.0
+	refreshTimeout  = 10 * time.Second
+)
+
+// CacheLoader fetches the value for a cache key from the source of truth
+type CacheLoader func(ctx context.Context) (interface{}, error)
+
+// cacheEnvelope wraps values stored by GetOrLoad with the metadata needed for
+// early expiry: when the value goes stale and how long it took to compute
+type cacheEnvelope struct {
+	Value      json.RawMessage `json:"v"`
+	FreshUntil int64           `json:"f"`
+	LoadMillis int64           `json:"d"`
+}
+
+type cacheStore func(ctx context.Context, key string, data []byte, expiry time.Duration) error
+
+// GetOrLoad reads key into result, calling loader on a miss. Concurrent misses for
+// the same key share one load, entries may be refreshed shortly before they expire,
+// and expired entries inside StaleWindow are served while one goroutine refreshes them.
+func (c *CacheLayer) GetOrLoad(ctx context.Context, key string, ttl time.Duration, result interface{}, loader CacheLoader) error {
+	return c.getOrLoad(ctx, key, ttl, result, loader, func(ctx context.Context, key string, data []byte, expiry time.Duration) error {
+		return c.store.Set(ctx, key, data, expiry)
+	})
+}
+
+// GetOrLoadPropertyList is GetOrLoad for property lists, tracking the key under its scope
+func (c *CacheLayer) GetOrLoadPropertyList(ctx context.Context, scope string, key string, ttl time.Duration, result interface{}, loader CacheLoader) error {
+	return c.getOrLoad(ctx, key, ttl, result, loader, func(ctx context.Context, key string, data []byte, expiry time.Duration) error {
+		return c.store.Set(ctx, key, data, expiry, PropertiesKeyPrefix+scope)
+	})
+}
+
+func (c *CacheLayer) getOrLoad(ctx context.Context, key string, ttl time.Duration, result interface{}, loader CacheLoader, store cacheStore) error {
+	var envelope cacheEnvelope
+	raw, found, err := c.store.Get(ctx, key)
+	if found && json.Unmarshal(raw, &envelope) == nil && envelope.Value != nil {
+		now := time.Now()
+		freshUntil := time.UnixMilli(envelope.FreshUntil)
+
+		switch {
+		case now.Before(freshUntil) && !shouldRefreshEarly(now, freshUntil, envelope.LoadMillis):
+			recordCacheLookup(key, cacheHit)
+			return json.Unmarshal(envelope.Value, result)
+		case now.Before(freshUntil):
+			// Close to expiry: this caller refreshes in the background, everyone keeps hitting
+			recordCacheLookup(key, cacheHit)
+			recordEarlyRefresh(key)
+			c.refreshInBackground(key, ttl, loader, store)
+			return json.Unmarshal(envelope.Value, result)
+		case c.serveStale:
+			recordCacheLookup(key, cacheStale)
+			c.refreshInBackground(key, ttl, loader, store)
+			return json.Unmarshal(envelope.Value, result)
+		}
+	} else if err != nil {
+		slog.WarnContext(ctx, "Error reading from cache", "key", key, "error", err)
+	}
+
+	recordCacheLookup(key, cacheMiss)
+	// The load is shared by every caller waiting on key, so it must not be cut short
+	// when the caller that started it goes away; each caller still stops waiting on
+	// its own cancellation
+	loaded := c.loadGroup.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+
+		return loadAndStore(loadCtx, key, ttl, loader, store)
+	})
+
+	select {
+	case res := <-loaded:
+		if res.Err != nil {
+			return res.Err
+		}
+		return json.Unmarshal(res.Val.([]byte), result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *CacheLayer) refreshInBackground(key string, ttl time.Duration, loader CacheLoader, store cacheStore) {
+	// DoChan joins a refresh already in flight instead of starting another
+	c.loadGroup.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		data, err := loadAndStore(ctx, key, ttl, loader, store)
+		if err != nil {
+			slog.WarnContext(ctx, "Error refreshing cache entry in background", "key", key, "error", err)
+		}
+		return data, err
+	})
+}
+
+// loadAndStore runs the loader and caches the result, returning its JSON encoding
+func loadAndStore(ctx context.Context, key string, ttl time.Duration, loader CacheLoader, store cacheStore) ([]byte, error) {
+	start := time.Now()
+	value, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loadTime := time.Since(start)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := json.Marshal(cacheEnvelope{
+		Value:      data,
+		FreshUntil: time.Now().Add(ttl).UnixMilli(),
+		LoadMillis: loadTime.Milliseconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store(ctx, key, envelope, ttl+StaleWindow); err != nil {
+		slog.WarnContext(ctx, "Error caching", "key", key, "error", err)
+	}
+
+	return data, nil
+}
+
+// shouldRefreshEarly implements probabilistic early expiration (XFetch): the closer an
+// entry is to expiry and the slower it was to compute, the likelier a refresh is
+func shouldRefreshEarly(now time.Time, freshUntil time.Time, loadMillis int64) bool {
+	delta := float64(loadMillis)
+	if delta < 1 {
+		delta = 1
 	}
+	gap := -delta * earlyExpiryBeta * math.Log(rand.Float64())
+	return float64(now.UnixMilli())+gap >= float64(freshUntil.UnixMilli())
 }
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/cache"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrLoadSharedLoadOutlivesCaller(t *testing.T) {
+	c := NewCacheLayer(cache.NewMemory(100), false)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return "value", ctx.Err()
+	}
+
+	// The first caller starts the load and then goes away
+	callerCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		var result string
+		firstErr <- c.GetOrLoad(callerCtx, "key", time.Minute, &result, loader)
+	}()
+	<-started
+
+	// A second caller joins the same load
+	secondResult := make(chan string, 1)
+	go func() {
+		var result string
+		if err := c.GetOrLoad(context.Background(), "key", time.Minute, &result, loader); err == nil {
+			secondResult <- result
+		}
+		close(secondResult)
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "value", <-secondResult)
+
+	var cached string
+	require.NoError(t, c.GetOrLoad(context.Background(), "key", time.Minute, &cached, loader))
+	assert.Equal(t, "value", cached)
+}
+
+func TestTimeouts(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+
+	ctx, done := Timeouts{Read: time.Minute}.read(parent)
+	defer done()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	// A zero timeout leaves only the caller's deadline, and cancellation still flows through
+	ctx, done = Timeouts{}.write(parent)
+	defer done()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,518 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxCollectionNameLength = 100
+
+// ListCollections returns all of a user's shortlists, creating the default one if needed
+func (r *MongoFavoriteRepository) ListCollections(ctx context.Context, userID string) ([]models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := r.ensureDefaultCollection(dbCtx, userObjID); err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "isDefault", Value: -1}, {Key: "createdAt", Value: 1}})
+	cursor, err := collection.Find(dbCtx, bson.M{"userId": userObjID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(dbCtx)
+
+	collections := []models.FavoriteCollection{}
+	if err := cursor.All(dbCtx, &collections); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// CreateCollection adds a new named shortlist for a user
+func (r *MongoFavoriteRepository) CreateCollection(ctx context.Context, userID string, name string) (*models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	name, err = validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	count, err := collection.CountDocuments(dbCtx, bson.M{"userId": userObjID, "name": name})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDuplicateCollection
+	}
+
+	now := time.Now()
+	favoriteCollection := models.FavoriteCollection{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjID,
+		Name:      name,
+		Items:     []models.FavoriteItem{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := collection.InsertOne(dbCtx, favoriteCollection); err != nil {
+		return nil, err
+	}
+
+	return &favoriteCollection, nil
+}
+
+// GetCollection fetches a shortlist owned by ownerID that actorID owns or collaborates on
+func (r *MongoFavoriteRepository) GetCollection(ctx context.Context, ownerID string, actorID string, collectionID string) (*models.FavoriteCollection, error) {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var favoriteCollection models.FavoriteCollection
+	err = collection.FindOne(dbCtx, filter).Decode(&favoriteCollection)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	return &favoriteCollection, nil
+}
+
+// GetCollectionItems resolves the items of a shortlist to their listings, newest first
+func (r *MongoFavoriteRepository) GetCollectionItems(ctx context.Context, favoriteCollection *models.FavoriteCollection) ([]models.FavoriteItemDetail, error) {
+	if len(favoriteCollection.Items) == 0 {
+		return []models.FavoriteItemDetail{}, nil
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	ids := make([]primitive.ObjectID, 0, len(favoriteCollection.Items))
+	for _, item := range favoriteCollection.Items {
+		ids = append(ids, item.PropertyID)
+	}
+
+	cursor, err := r.db.Collection("properties").Find(dbCtx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(dbCtx)
+
+	var properties []models.Property
+	if err := cursor.All(dbCtx, &properties); err != nil {
+		return nil, err
+	}
+
+	return collectionItemDetails(favoriteCollection.Items, properties), nil
+}
+
+// RenameCollection changes the name of one of the user's shortlists
+func (r *MongoFavoriteRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (*models.FavoriteCollection, error) {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err = validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	count, err := collection.CountDocuments(dbCtx, bson.M{
+		"userId": filter["userId"],
+		"name":   name,
+		"_id":    bson.M{"$ne": filter["_id"]},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrDuplicateCollection
+	}
+
+	var updated models.FavoriteCollection
+	err = collection.FindOneAndUpdate(dbCtx, filter,
+		bson.M{"$set": bson.M{"name": name, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteCollection removes one of the user's shortlists. The default collection cannot be deleted.
+func (r *MongoFavoriteRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) error {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	var favoriteCollection models.FavoriteCollection
+	err = collection.FindOne(dbCtx, filter).Decode(&favoriteCollection)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrCollectionNotFound
+		}
+		return err
+	}
+
+	if favoriteCollection.IsDefault {
+		return ErrDefaultCollection
+	}
+
+	_, err = collection.DeleteOne(dbCtx, filter)
+	if err != nil {
+		return err
+	}
+
+	r.cache.ClearPersonalisedRecommendationsCache(ctx, userID)
+
+	return nil
+}
+
+// AddCollectionItem saves a property into a shortlist with an optional note.
+// The actor must be the owner or a collaborator.
+func (r *MongoFavoriteRepository) AddCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, note string) error {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	actorObjID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if err := r.ensurePropertyVisible(dbCtx, propObjID, actorObjID); err != nil {
+		return err
+	}
+
+	added, err := r.pushCollectionItem(dbCtx, filter, propObjID, note, actorObjID)
+	if err != nil {
+		return err
+	}
+
+	if !added {
+		count, err := collection.CountDocuments(dbCtx, filter)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrCollectionNotFound
+		}
+		return ErrAlreadyInCollection
+	}
+
+	r.clearCollectionCaches(ctx, ownerID)
+
+	return nil
+}
+
+// UpdateCollectionItemNote replaces the private note on a saved property
+func (r *MongoFavoriteRepository) UpdateCollectionItemNote(ctx context.Context, userID string, collectionID string, propertyID string, note string) error {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	filter["items.propertyId"] = propObjID
+	result, err := collection.UpdateOne(dbCtx, filter, bson.M{
+		"$set": bson.M{"items.$.note": note, "updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotInCollection
+	}
+
+	return nil
+}
+
+// RemoveCollectionItem removes a saved property from a shortlist.
+// The actor must be the owner or a collaborator.
+func (r *MongoFavoriteRepository) RemoveCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string) error {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	removed, err := r.pullCollectionItem(dbCtx, filter, propObjID)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		count, err := collection.CountDocuments(dbCtx, filter)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrCollectionNotFound
+		}
+		return ErrNotInCollection
+	}
+
+	r.clearCollectionCaches(ctx, ownerID)
+
+	return nil
+}
+
+// favoritePropertyIDs returns every property a user has saved across all collections
+func favoritePropertyIDs(ctx context.Context, db *mongo.Database, userObjID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := db.Collection("favorite_collections").Find(ctx,
+		bson.M{"userId": userObjID},
+		options.Find().SetProjection(bson.M{"items.propertyId": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collections []models.FavoriteCollection
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, favoriteCollection := range collections {
+		for _, item := range favoriteCollection.Items {
+			ids = append(ids, item.PropertyID)
+		}
+	}
+
+	return dedupeObjectIDs(ids), nil
+}
+
+func (r *MongoFavoriteRepository) ensureDefaultCollection(ctx context.Context, userObjID primitive.ObjectID) (*models.FavoriteCollection, error) {
+	now := time.Now()
+	var defaultCollection models.FavoriteCollection
+	err := r.db.Collection("favorite_collections").FindOneAndUpdate(ctx,
+		bson.M{"userId": userObjID, "isDefault": true},
+		bson.M{"$setOnInsert": bson.M{
+			"name":      models.DefaultCollectionName,
+			"items":     []models.FavoriteItem{},
+			"createdAt": now,
+			"updatedAt": now,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&defaultCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	return &defaultCollection, nil
+}
+
+// pushCollectionItem appends the property unless the collection already holds it
+func (r *MongoFavoriteRepository) pushCollectionItem(ctx context.Context, filter bson.M, propObjID primitive.ObjectID, note string, addedBy primitive.ObjectID) (bool, error) {
+	itemFilter := bson.M{"items.propertyId": bson.M{"$ne": propObjID}}
+	for key, value := range filter {
+		itemFilter[key] = value
+	}
+
+	now := time.Now()
+	result, err := r.db.Collection("favorite_collections").UpdateOne(ctx, itemFilter, bson.M{
+		"$push": bson.M{"items": models.FavoriteItem{PropertyID: propObjID, Note: note, SavedAt: now, AddedBy: addedBy}},
+		"$set":  bson.M{"updatedAt": now},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r *MongoFavoriteRepository) pullCollectionItem(ctx context.Context, filter bson.M, propObjID primitive.ObjectID) (bool, error) {
+	itemFilter := bson.M{"items.propertyId": propObjID}
+	for key, value := range filter {
+		itemFilter[key] = value
+	}
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(ctx, itemFilter, bson.M{
+		"$pull": bson.M{"items": bson.M{"propertyId": propObjID}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ensurePropertyVisible checks that the property exists and, as drafts are only
+// shown to their owner, that it is not someone else's draft
+func (r *MongoFavoriteRepository) ensurePropertyVisible(ctx context.Context, propObjID primitive.ObjectID, userObjID primitive.ObjectID) error {
+	count, err := r.db.Collection("properties").CountDocuments(ctx, bson.M{
+		"_id": propObjID,
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$ne": models.PropertyDraft}},
+			bson.M{"createdBy": userObjID},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPropertyNotFound
+	}
+	return nil
+}
+
+// clearCollectionCaches drops the caches derived from a user's shortlists
+func (r *MongoFavoriteRepository) clearCollectionCaches(ctx context.Context, userID string) {
+	r.cache.ClearUserFavoritesCache(ctx, userID)
+	r.cache.ClearPersonalisedRecommendationsCache(ctx, userID)
+}
+
+func collectionOwnerFilter(userID string, collectionID string) (bson.M, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	collectionObjID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return nil, ErrInvalidCollectionID
+	}
+
+	return bson.M{"_id": collectionObjID, "userId": userObjID}, nil
+}
+
+// collectionMemberFilter matches a collection of ownerID that actorID owns or collaborates on
+func collectionMemberFilter(ownerID string, actorID string, collectionID string) (bson.M, error) {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorID != ownerID {
+		actorObjID, err := primitive.ObjectIDFromHex(actorID)
+		if err != nil {
+			return nil, ErrInvalidUserID
+		}
+		filter["collaborators"] = actorObjID
+	}
+
+	return filter, nil
+}
+
+// collectionItemDetails pairs saved items with their listings, newest first, skipping
+// listings that no longer exist
+func collectionItemDetails(savedItems []models.FavoriteItem, properties []models.Property) []models.FavoriteItemDetail {
+	propertyMap := make(map[primitive.ObjectID]models.Property)
+	for _, property := range properties {
+		propertyMap[property.ID] = property
+	}
+
+	items := []models.FavoriteItemDetail{}
+	for i := len(savedItems) - 1; i >= 0; i-- {
+		item := savedItems[i]
+		property, ok := propertyMap[item.PropertyID]
+		if !ok {
+			continue
+		}
+
+		detail := models.FavoriteItemDetail{
+			Property: property,
+			Note:     item.Note,
+			SavedAt:  item.SavedAt,
+			AddedBy:  item.AddedBy,
+			Comments: item.Comments,
+		}
+		for _, reaction := range item.Reactions {
+			switch reaction.Value {
+			case models.ReactionUp:
+				detail.ThumbsUp++
+			case models.ReactionDown:
+				detail.ThumbsDown++
+			}
+		}
+		items = append(items, detail)
+	}
+
+	return items
+}
+
+func validateCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrCollectionNameRequired
+	}
+	if len(name) > maxCollectionNameLength {
+		return "", ErrCollectionNameTooLong
+	}
+	return name, nil
+}
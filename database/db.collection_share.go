@@ -0,0 +1,336 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxCommentLength = 1000
+
+// CreateShareLink issues a new read-only share token for a collection, replacing any previous one
+func (r *MongoFavoriteRepository) CreateShareLink(ctx context.Context, ownerID string, collectionID string) (string, error) {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return "", err
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$set": bson.M{"shareToken": token, "updatedAt": time.Now()},
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		return "", ErrCollectionNotFound
+	}
+
+	return token, nil
+}
+
+// RevokeShareLink disables the read-only link of a collection
+func (r *MongoFavoriteRepository) RevokeShareLink(ctx context.Context, ownerID string, collectionID string) error {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$unset": bson.M{"shareToken": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCollectionNotFound
+	}
+
+	return nil
+}
+
+// GetSharedCollection looks up a collection by its share token
+func (r *MongoFavoriteRepository) GetSharedCollection(ctx context.Context, token string) (*models.FavoriteCollection, error) {
+	if token == "" {
+		return nil, ErrCollectionNotFound
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var favoriteCollection models.FavoriteCollection
+	err := r.db.Collection("favorite_collections").FindOne(dbCtx, bson.M{"shareToken": token}).Decode(&favoriteCollection)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	return &favoriteCollection, nil
+}
+
+// AddCollaborator lets another user co-edit one of the owner's collections
+func (r *MongoFavoriteRepository) AddCollaborator(ctx context.Context, ownerID string, collectionID string, collaboratorID string) error {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	if collaboratorID == ownerID {
+		return ErrOwnerAsCollaborator
+	}
+
+	collaboratorObjID, err := primitive.ObjectIDFromHex(collaboratorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	count, err := r.db.Collection("users").CountDocuments(dbCtx, bson.M{"_id": collaboratorObjID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$addToSet": bson.M{"collaborators": collaboratorObjID},
+		"$set":      bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCollectionNotFound
+	}
+
+	return nil
+}
+
+// RemoveCollaborator revokes co-editing. The owner may remove anyone and a
+// collaborator may remove themselves.
+func (r *MongoFavoriteRepository) RemoveCollaborator(ctx context.Context, ownerID string, actorID string, collectionID string, collaboratorID string) error {
+	if actorID != ownerID && actorID != collaboratorID {
+		return ErrCollectionNotFound
+	}
+
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	collaboratorObjID, err := primitive.ObjectIDFromHex(collaboratorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	filter["collaborators"] = collaboratorObjID
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$pull": bson.M{"collaborators": collaboratorObjID},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCollaboratorNotFound
+	}
+
+	return nil
+}
+
+// ListSharedWithUser returns the collections other users have shared with userID,
+// without the share links and notes that stay private to their owners
+func (r *MongoFavoriteRepository) ListSharedWithUser(ctx context.Context, userID string) ([]models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: -1}}).
+		SetProjection(bson.M{"shareToken": 0, "items.note": 0})
+	cursor, err := r.db.Collection("favorite_collections").Find(dbCtx, bson.M{"collaborators": userObjID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(dbCtx)
+
+	collections := []models.FavoriteCollection{}
+	if err := cursor.All(dbCtx, &collections); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// AddItemComment attaches a comment from a member to a saved property
+func (r *MongoFavoriteRepository) AddItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, text string) (*models.ItemComment, error) {
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrCommentRequired
+	}
+	if len(text) > maxCommentLength {
+		return nil, ErrCommentTooLong
+	}
+
+	actorObjID, _ := primitive.ObjectIDFromHex(actorID)
+	comment := models.ItemComment{
+		ID:        primitive.NewObjectID(),
+		UserID:    actorObjID,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$push": bson.M{"items.$.comments": comment},
+		"$set":  bson.M{"updatedAt": comment.CreatedAt},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrNotInCollection
+	}
+
+	return &comment, nil
+}
+
+// DeleteItemComment removes a comment. Members may delete their own comments
+// and the owner may delete any comment.
+func (r *MongoFavoriteRepository) DeleteItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, commentID string) error {
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return err
+	}
+
+	commentObjID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return ErrInvalidCommentID
+	}
+
+	match := bson.M{"_id": commentObjID}
+	if actorID != ownerID {
+		actorObjID, _ := primitive.ObjectIDFromHex(actorID)
+		match["userId"] = actorObjID
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	filter["items"] = bson.M{"$elemMatch": bson.M{"propertyId": filter["items.propertyId"], "comments": bson.M{"$elemMatch": match}}}
+	delete(filter, "items.propertyId")
+
+	result, err := r.db.Collection("favorite_collections").UpdateOne(dbCtx, filter, bson.M{
+		"$pull": bson.M{"items.$.comments": match},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// SetItemReaction records a member's thumbs up or down on a saved property.
+// An empty value clears the member's reaction.
+func (r *MongoFavoriteRepository) SetItemReaction(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, value string) error {
+	if value != "" && value != models.ReactionUp && value != models.ReactionDown {
+		return ErrInvalidReaction
+	}
+
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return err
+	}
+
+	actorObjID, _ := primitive.ObjectIDFromHex(actorID)
+	collection := r.db.Collection("favorite_collections")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	// Drop the member's previous reaction before recording the new one
+	result, err := collection.UpdateOne(dbCtx, filter, bson.M{
+		"$pull": bson.M{"items.$.reactions": bson.M{"userId": actorObjID}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotInCollection
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	reaction := models.ItemReaction{UserID: actorObjID, Value: value, ReactedAt: time.Now()}
+	_, err = collection.UpdateOne(dbCtx, filter, bson.M{
+		"$push": bson.M{"items.$.reactions": reaction},
+		"$set":  bson.M{"updatedAt": reaction.ReactedAt},
+	})
+
+	return err
+}
+
+// collectionItemFilter matches a saved property in a collection the actor is a member of
+func collectionItemFilter(ownerID string, actorID string, collectionID string, propertyID string) (bson.M, error) {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := primitive.ObjectIDFromHex(actorID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return nil, ErrInvalidPropertyID
+	}
+	filter["items.propertyId"] = propObjID
+
+	return filter, nil
+}
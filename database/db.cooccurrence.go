@@ -0,0 +1,232 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxFavoritesPerUser bounds the pairs generated by a single user so one
+	// enormous shortlist cannot dominate the co-occurrence counts
+	maxFavoritesPerUser = 200
+	// minSupportFloor is enforced regardless of configuration. The support is
+	// shown with each neighbour, so a user who saved both listings of a pair with
+	// a low support would learn what the few other users shortlisted.
+	minSupportFloor = 5
+)
+
+type propertyPair struct {
+	a, b primitive.ObjectID
+}
+
+// ComputeCooccurrence counts how often each pair of properties appears in the
+// same user's collections and stores the topN neighbours of every property. Pairs saved
+// together by fewer than minSupport users are dropped so no individual choice is exposed.
+// It returns the number of properties that received neighbours.
+func (r *MongoRecommendationRepository) ComputeCooccurrence(ctx context.Context, minSupport int, topN int) (int, error) {
+	ctx, cancel := r.timeouts.batch(ctx)
+	defer cancel()
+
+	neighboursCollection := r.db.Collection("property_neighbours")
+
+	runStart := time.Now()
+
+	// One list per user, merging all of their collections
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$userId",
+			"favorites": bson.M{"$addToSet": "$items.propertyId"},
+		}}},
+		{{Key: "$match", Value: bson.M{"favorites.1": bson.M{"$exists": true}}}},
+	}
+
+	cursor, err := r.db.Collection("favorite_collections").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var favoriteLists [][]primitive.ObjectID
+	for cursor.Next(ctx) {
+		var userFavorites struct {
+			Favorites []primitive.ObjectID `bson:"favorites"`
+		}
+		if err := cursor.Decode(&userFavorites); err != nil {
+			slog.ErrorContext(ctx, "Error decoding user favorites for co-occurrence", "error", err)
+			continue
+		}
+		favoriteLists = append(favoriteLists, userFavorites.Favorites)
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	neighbours := BuildCooccurrence(favoriteLists, minSupport, topN)
+
+	if len(neighbours) > 0 {
+		writes := make([]mongo.WriteModel, 0, len(neighbours))
+		for propertyID, list := range neighbours {
+			doc := models.PropertyNeighbours{
+				PropertyID: propertyID,
+				Neighbours: list,
+				ComputedAt: runStart,
+			}
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": propertyID}).
+				SetReplacement(doc).
+				SetUpsert(true))
+		}
+
+		if _, err := neighboursCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return 0, err
+		}
+	}
+
+	// Anything not refreshed in this run fell below the support threshold
+	_, err = neighboursCollection.DeleteMany(ctx, bson.M{"computedAt": bson.M{"$lt": runStart}})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(neighbours), nil
+}
+
+// BuildCooccurrence turns favorite lists into the ranked neighbours of every property
+func BuildCooccurrence(favoriteLists [][]primitive.ObjectID, minSupport int, topN int) map[primitive.ObjectID][]models.Neighbour {
+	if minSupport < minSupportFloor {
+		minSupport = minSupportFloor
+	}
+
+	counts := make(map[propertyPair]int)
+	for _, favorites := range favoriteLists {
+		unique := dedupeObjectIDs(favorites)
+		if len(unique) > maxFavoritesPerUser {
+			unique = unique[:maxFavoritesPerUser]
+		}
+		for i := 0; i < len(unique); i++ {
+			for j := i + 1; j < len(unique); j++ {
+				counts[orderedPair(unique[i], unique[j])]++
+			}
+		}
+	}
+
+	neighbours := make(map[primitive.ObjectID][]models.Neighbour)
+	for pair, support := range counts {
+		if support < minSupport {
+			continue
+		}
+		neighbours[pair.a] = append(neighbours[pair.a], models.Neighbour{PropertyID: pair.b, Support: support})
+		neighbours[pair.b] = append(neighbours[pair.b], models.Neighbour{PropertyID: pair.a, Support: support})
+	}
+
+	for propertyID, list := range neighbours {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Support == list[j].Support {
+				return bytes.Compare(list[i].PropertyID[:], list[j].PropertyID[:]) < 0
+			}
+			return list[i].Support > list[j].Support
+		})
+		if len(list) > topN {
+			list = list[:topN]
+		}
+		neighbours[propertyID] = list
+	}
+
+	return neighbours
+}
+
+// GetAlsoSaved returns the listings most often saved together with a property
+func (r *MongoRecommendationRepository) GetAlsoSaved(ctx context.Context, propertyID string) ([]models.AlsoSavedProperty, error) {
+	cacheKey := AlsoSavedKeyPrefix + propertyID
+	var alsoSaved []models.AlsoSavedProperty
+	found, err := r.cache.GetFromCache(ctx, cacheKey, &alsoSaved)
+	if err != nil {
+		slog.WarnContext(ctx, "Error retrieving also-saved properties from cache", "error", err)
+	}
+
+	if found {
+		return alsoSaved, nil
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return nil, ErrInvalidPropertyID
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	alsoSaved = []models.AlsoSavedProperty{}
+
+	var doc models.PropertyNeighbours
+	err = r.db.Collection("property_neighbours").FindOne(dbCtx, bson.M{"_id": propObjID}).Decode(&doc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if len(doc.Neighbours) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(doc.Neighbours))
+		for _, neighbour := range doc.Neighbours {
+			ids = append(ids, neighbour.PropertyID)
+		}
+
+		cursor, err := r.db.Collection("properties").Find(dbCtx, bson.M{"_id": bson.M{"$in": ids}, "status": models.PropertyActive})
+		if err != nil {
+			return nil, err
+		}
+		var properties []models.Property
+		if err := cursor.All(dbCtx, &properties); err != nil {
+			return nil, err
+		}
+
+		propertyMap := make(map[primitive.ObjectID]models.Property)
+		for _, property := range properties {
+			propertyMap[property.ID] = property
+		}
+
+		// Keep the ranking order; neighbours deleted or taken off the market since the
+		// last run are skipped
+		for _, neighbour := range doc.Neighbours {
+			if property, ok := propertyMap[neighbour.PropertyID]; ok {
+				alsoSaved = append(alsoSaved, models.AlsoSavedProperty{Property: property, Support: neighbour.Support})
+			}
+		}
+	}
+
+	err = r.cache.SetInCache(ctx, cacheKey, alsoSaved, ShortTerm)
+	if err != nil {
+		slog.WarnContext(ctx, "Error caching also-saved properties", "error", err)
+	}
+
+	return alsoSaved, nil
+}
+
+func orderedPair(x, y primitive.ObjectID) propertyPair {
+	if bytes.Compare(x[:], y[:]) > 0 {
+		x, y = y, x
+	}
+	return propertyPair{a: x, b: y}
+}
+
+func dedupeObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	unique := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
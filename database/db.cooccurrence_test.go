@@ -0,0 +1,28 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildCooccurrenceSupportFloor(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	lists := func(users int) [][]primitive.ObjectID {
+		favoriteLists := make([][]primitive.ObjectID, users)
+		for i := range favoriteLists {
+			favoriteLists[i] = []primitive.ObjectID{a, b}
+		}
+		return favoriteLists
+	}
+
+	// A configured support below the floor cannot expose a handful of users' choices
+	assert.Empty(t, BuildCooccurrence(lists(minSupportFloor-1), 1, 10))
+
+	neighbours := BuildCooccurrence(lists(minSupportFloor), 1, 10)
+	if assert.Len(t, neighbours[a], 1) {
+		assert.Equal(t, b, neighbours[a][0].PropertyID)
+		assert.Equal(t, minSupportFloor, neighbours[a][0].Support)
+	}
+}
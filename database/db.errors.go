@@ -0,0 +1,41 @@
+package database
+
+import "Praiseson6065/Hypergro-assign/apperror"
+
+// Errors returned by the repositories. Each has an apperror kind, so callers can
+// test for a specific error with errors.Is or for a whole class, e.g.
+// errors.Is(err, apperror.ErrNotFound).
+var (
+	ErrInvalidUserID         = apperror.Validation("invalid_user_id", "invalid user ID format")
+	ErrInvalidPropertyID     = apperror.Validation("invalid_property_id", "invalid property ID format")
+	ErrInvalidCollectionID   = apperror.Validation("invalid_collection_id", "invalid collection ID format")
+	ErrInvalidCommentID      = apperror.Validation("invalid_comment_id", "invalid comment ID format")
+	ErrInvalidNotificationID = apperror.Validation("invalid_notification_id", "invalid notification ID format")
+	ErrInvalidRecommenderID  = apperror.Validation("invalid_recommender_id", "invalid recommender user ID format")
+	ErrInvalidRecipientID    = apperror.Validation("invalid_recipient_id", "invalid recipient user ID format")
+
+	ErrCollectionNameRequired = apperror.Validation("collection_name_required", "collection name is required")
+	ErrCollectionNameTooLong  = apperror.Validation("collection_name_too_long", "collection name is too long")
+	ErrOwnerAsCollaborator    = apperror.Validation("owner_as_collaborator", "the owner cannot be added as a collaborator")
+	ErrCommentRequired        = apperror.Validation("comment_required", "comment text is required")
+	ErrCommentTooLong         = apperror.Validation("comment_too_long", "comment is too long")
+	ErrInvalidReaction        = apperror.Validation("invalid_reaction", "reaction must be up or down")
+
+	ErrUserNotFound         = apperror.NotFound("user_not_found", "user not found")
+	ErrRecipientNotFound    = apperror.NotFound("recipient_not_found", "recipient user not found")
+	ErrPropertyNotFound     = apperror.NotFound("property_not_found", "property not found")
+	ErrCollectionNotFound   = apperror.NotFound("collection_not_found", "collection not found")
+	ErrCollaboratorNotFound = apperror.NotFound("collaborator_not_found", "collaborator not found")
+	ErrCommentNotFound      = apperror.NotFound("comment_not_found", "comment not found")
+	ErrNotificationNotFound = apperror.NotFound("notification_not_found", "notification not found")
+	ErrNotInFavorites       = apperror.NotFound("not_in_favorites", "property not in favorites")
+	ErrNotInCollection      = apperror.NotFound("not_in_collection", "property not in collection")
+	ErrPropertyNotOwned     = apperror.Forbidden("property_not_owned", "you can only change properties you created")
+	ErrAlreadyInFavorites   = apperror.Conflict("already_in_favorites", "property already in favorites")
+	ErrAlreadyInCollection  = apperror.Conflict("already_in_collection", "property already in collection")
+	ErrDuplicateCollection  = apperror.Conflict("duplicate_collection_name", "a collection with this name already exists")
+	ErrDefaultCollection    = apperror.Conflict("default_collection", "the default collection cannot be deleted")
+	ErrDuplicatePropertyID  = apperror.Conflict("duplicate_property_id", "duplicate property ID")
+	ErrPropertyChanged      = apperror.Conflict("property_changed", "the property changed while the update was applied, retry")
+	ErrVersionMismatch      = apperror.New(apperror.ErrPreconditionFailed, "version_mismatch", "the property has changed since it was read")
+)
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFavoriteRepository is the FavoriteRepository backed by the favorite_collections collection
+type MongoFavoriteRepository struct {
+	db       *mongo.Database
+	cache    *CacheLayer
+	timeouts Timeouts
+}
+
+func NewMongoFavoriteRepository(db *mongo.Database, cache *CacheLayer, timeouts Timeouts) *MongoFavoriteRepository {
+	return &MongoFavoriteRepository{db: db, cache: cache, timeouts: timeouts}
+}
+
+// ListFavorites retrieves the properties in a user's default collection
+func (r *MongoFavoriteRepository) ListFavorites(ctx context.Context, userID string) ([]models.Property, error) {
+	// Try to get from cache first
+	cacheKey := UserFavoritesKeyPrefix + userID
+	var favoriteProperties []models.Property
+	found, err := r.cache.GetFromCache(ctx, cacheKey, &favoriteProperties)
+	if err != nil {
+		slog.WarnContext(ctx, "Error retrieving user favorites from cache", "error", err)
+	}
+
+	if found {
+		return favoriteProperties, nil
+	}
+
+	// Not in cache, get from database
+	usersCollection := r.db.Collection("users")
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	// Convert userID to ObjectID
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	// Make sure the user exists before creating their default collection
+	count, err := usersCollection.CountDocuments(dbCtx, bson.M{"_id": userObjID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	defaultCollection, err := r.ensureDefaultCollection(dbCtx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := r.GetCollectionItems(ctx, defaultCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	favoriteProperties = make([]models.Property, 0, len(items))
+	for _, item := range items {
+		favoriteProperties = append(favoriteProperties, item.Property)
+	}
+
+	// Store in cache for future requests
+	err = r.cache.SetInCache(ctx, cacheKey, favoriteProperties, MediumTerm)
+	if err != nil {
+		slog.WarnContext(ctx, "Error caching user favorites", "error", err)
+	}
+
+	return favoriteProperties, nil
+}
+
+// AddFavorite adds a property to a user's default collection
+func (r *MongoFavoriteRepository) AddFavorite(ctx context.Context, userID string, propertyID string) error {
+	usersCollection := r.db.Collection("users")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	// Convert IDs to ObjectIDs
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	// Check if property exists and the user may see it
+	if err := r.ensurePropertyVisible(dbCtx, propObjID, userObjID); err != nil {
+		return err
+	}
+
+	// Check if user exists
+	count, err := usersCollection.CountDocuments(dbCtx, bson.M{"_id": userObjID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+
+	defaultCollection, err := r.ensureDefaultCollection(dbCtx, userObjID)
+	if err != nil {
+		return err
+	}
+
+	// Add to favorites only if not already in favorites
+	added, err := r.pushCollectionItem(dbCtx, bson.M{"_id": defaultCollection.ID}, propObjID, "", userObjID)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrAlreadyInFavorites
+	}
+
+	// Clear the favorites cache for this user
+	r.clearCollectionCaches(ctx, userID)
+
+	return nil
+}
+
+// RemoveFavorite removes a property from a user's default collection
+func (r *MongoFavoriteRepository) RemoveFavorite(ctx context.Context, userID string, propertyID string) error {
+	usersCollection := r.db.Collection("users")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	// Convert IDs to ObjectIDs
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	// Remove from favorites
+	removed, err := r.pullCollectionItem(dbCtx, bson.M{"userId": userObjID, "isDefault": true}, propObjID)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		// Check if user exists
+		count, err := usersCollection.CountDocuments(dbCtx, bson.M{"_id": userObjID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+		// If user exists but nothing was removed, property was not in favorites
+		return ErrNotInFavorites
+	}
+
+	// Clear the favorites cache for this user
+	r.clearCollectionCaches(ctx, userID)
+
+	return nil
+}
+
+// FindUsersWhoSaved returns every user with the property in one of their collections
+func (r *MongoFavoriteRepository) FindUsersWhoSaved(ctx context.Context, propertyID primitive.ObjectID) ([]models.User, error) {
+	userIDs, err := r.db.Collection("favorite_collections").Distinct(ctx, "userId", bson.M{"items.propertyId": propertyID})
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return []models.User{}, nil
+	}
+
+	cursor, err := r.db.Collection("users").Find(ctx,
+		bson.M{"_id": bson.M{"$in": userIDs}},
+		options.Find().SetProjection(bson.M{"name": 1, "email": 1, "notificationPreferences": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
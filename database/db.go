@@ -1,16 +1,19 @@
 package database
 
 import (
-	_ "Praiseson6065/Hypergro-assign/config"
+	"Praiseson6065/Hypergro-assign/cache"
+	"Praiseson6065/Hypergro-assign/config"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
-	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 type DBConfig struct {
@@ -21,132 +24,219 @@ type DBConfig struct {
 	RedisPassword string
 	RedisDB       int
 	RedisTimeout  int
+	ServeStale    bool
+	CacheMode     string
+	L1MaxEntries  int
+	L1TTL         int
+	Timeouts      Timeouts
 }
 
-var env = viper.GetString("ENVIRONMENT")
-var MongoClient *mongo.Client
+// Timeouts bound each kind of database operation. They apply on top of the caller's
+// context, so a request that is cancelled still stops its queries early.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	// Batch bounds the background jobs that scan whole collections
+	Batch time.Duration
+}
+
+// DefaultTimeouts are used for any timeout missing from the config
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Read:  5 * time.Second,
+		Write: 10 * time.Second,
+		Batch: 10 * time.Minute,
+	}
+}
+
+func (t Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+func (t Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func (t Timeouts) batch(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Batch)
+}
 
-var RedisClient *redis.Client
+// withTimeout treats a zero timeout as none, leaving only the caller's deadline
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
 
-var dbConfig DBConfig
+// Cache modes selectable with cache.mode
+const (
+	CacheModeTiered = "tiered"
+	CacheModeRedis  = "redis"
+	CacheModeMemory = "memory"
+
+	defaultL1MaxEntries = 10000
+	defaultL1TTL        = 30 * time.Second
+)
 
-func InitDB(cfg DBConfig) error {
+// ConnectMongo connects to MongoDB, retrying while the server comes up
+func ConnectMongo(cfg DBConfig) (*mongo.Client, error) {
 	const maxRetries = 5
 	const retryDelay = 5 * time.Second
 
-	for i := 0; i < maxRetries; i++ {
-		if err := connectMongoDB(cfg); err == nil {
-			break
-		} else if i < maxRetries-1 {
-			log.Printf("MongoDB connection attempt %d failed: %v. Retrying in %v...", i+1, err, retryDelay)
-			time.Sleep(retryDelay)
-			continue
-		} else {
-			return fmt.Errorf("failed to connect to MongoDB after %d attempts: %w", maxRetries, err)
+	for i := 0; ; i++ {
+		client, err := connectMongoDB(cfg)
+		if err == nil {
+			return client, nil
 		}
-	}
-
-	for i := 0; i < maxRetries; i++ {
-		if err := connectRedis(cfg); err == nil {
-			break
-		} else if i < maxRetries-1 {
-			log.Printf("Redis connection attempt %d failed: %v. Retrying in %v...", i+1, err, retryDelay)
-			time.Sleep(retryDelay)
-			continue
-		} else {
-			return fmt.Errorf("failed to connect to Redis after %d attempts: %w", maxRetries, err)
+		if i == maxRetries-1 {
+			return nil, fmt.Errorf("failed to connect to MongoDB after %d attempts: %w", maxRetries, err)
 		}
+		slog.Warn("MongoDB connection attempt failed, retrying", "attempt", i+1, "retry_in", retryDelay.String(), "error", err)
+		time.Sleep(retryDelay)
 	}
-
-	return nil
 }
 
-func connectMongoDB(cfg DBConfig) error {
+func connectMongoDB(cfg DBConfig) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.MongoTimeout)*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(cfg.MongoURI)
+	// Every command is timed for metrics and traced as a child of the caller's span
+	clientOptions := options.Client().ApplyURI(cfg.MongoURI).
+		SetMonitor(commandMonitors(newCommandMonitor(), otelmongo.NewMonitor()))
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
-		return err
+		client.Disconnect(context.Background())
+		return nil, err
 	}
 
-	MongoClient = client
-	log.Println("Connected to MongoDB successfully")
-	return nil
+	slog.Info("Connected to MongoDB")
+	return client, nil
+}
+
+// commandMonitors fans the events of one MongoDB client out to several monitors
+func commandMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, evt)
+				}
+			}
+		},
+	}
 }
 
-func connectRedis(cfg DBConfig) error {
-	RedisClient = redis.NewClient(&redis.Options{
+// ConnectRedis creates the Redis client and reports whether Redis answered. The client
+// is returned even if the ping fails; it reconnects on its own once Redis is back.
+func ConnectRedis(cfg DBConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
 		Addr:        cfg.RedisAddr,
 		Password:    cfg.RedisPassword,
 		DB:          cfg.RedisDB,
 		DialTimeout: time.Duration(cfg.RedisTimeout) * time.Second,
 	})
 
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		slog.Warn("Error instrumenting Redis for tracing", "error", err)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RedisTimeout)*time.Second)
 	defer cancel()
 
-	_, err := RedisClient.Ping(ctx).Result()
-	if err != nil {
-		return err
+	if err := client.Ping(ctx).Err(); err != nil {
+		return client, err
 	}
 
-	log.Println("Connected to Redis successfully")
-	return nil
+	slog.Info("Connected to Redis")
+	return client, nil
 }
 
-func GetMongoDB() *mongo.Database {
+// NewCache builds the cache selected by cfg.CacheMode. redisClient may be nil in memory mode.
+func NewCache(cfg DBConfig, redisClient *redis.Client, redisUp bool) cache.Cache {
+	maxEntries := cfg.L1MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultL1MaxEntries
+	}
+	l1TTL := time.Duration(cfg.L1TTL) * time.Second
+	if l1TTL <= 0 {
+		l1TTL = defaultL1TTL
+	}
 
-	return MongoClient.Database(viper.GetString(env + ".mongodb.database"))
+	switch cfg.CacheMode {
+	case CacheModeMemory:
+		slog.Info("Using in-memory cache")
+		return cache.NewMemory(maxEntries)
+	case CacheModeRedis:
+		slog.Info("Using Redis cache")
+		return cache.NewRedis(redisClient)
+	default:
+		slog.Info("Using two-tier cache (in-memory L1, Redis L2)")
+		return cache.NewTiered(cache.NewMemory(maxEntries), cache.NewRedis(redisClient), l1TTL, redisUp)
+	}
 }
 
-func CloseDB() {
-	if MongoClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := MongoClient.Disconnect(ctx); err != nil {
-			log.Printf("Error disconnecting from MongoDB: %v", err)
-		}
-		log.Println("Disconnected from MongoDB")
+// DisconnectMongo closes the MongoDB connection pool
+func DisconnectMongo(client *mongo.Client) {
+	if client == nil {
+		return
 	}
 
-	if RedisClient != nil {
-		if err := RedisClient.Close(); err != nil {
-			log.Printf("Error closing Redis connection: %v", err)
-		}
-		log.Println("Disconnected from Redis")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Disconnect(ctx); err != nil {
+		slog.Error("Error disconnecting from MongoDB", "error", err)
 	}
+	slog.Info("Disconnected from MongoDB")
 }
 
-func loadConfig() (DBConfig, error) {
-
+// NewDBConfig takes the database and cache settings out of the application config
+func NewDBConfig(cfg *config.Config) DBConfig {
 	return DBConfig{
-		MongoURI:      viper.GetString(env + ".mongodb.uri"),
-		MongoDBName:   viper.GetString(env + ".mongodb.database"),
-		MongoTimeout:  viper.GetInt(env + ".mongodb.timeout"),
-		RedisAddr:     viper.GetString(env + ".redis.addr"),
-		RedisPassword: viper.GetString(env + ".redis.password"),
-		RedisDB:       viper.GetInt(env + ".redis.db"),
-		RedisTimeout:  viper.GetInt(env + ".redis.timeout"),
-	}, nil
+		MongoURI:      cfg.MongoDB.URI,
+		MongoDBName:   cfg.MongoDB.Database,
+		MongoTimeout:  cfg.MongoDB.Timeout,
+		RedisAddr:     cfg.Redis.Addr,
+		RedisPassword: cfg.Redis.Password,
+		RedisDB:       cfg.Redis.DB,
+		RedisTimeout:  cfg.Redis.Timeout,
+		ServeStale:    cfg.Cache.ServeStale,
+		CacheMode:     cfg.Cache.Mode,
+		L1MaxEntries:  cfg.Cache.L1MaxEntries,
+		L1TTL:         cfg.Cache.L1TTL,
+		Timeouts:      newTimeouts(cfg.MongoDB.Timeouts),
+	}
 }
 
-func init() {
-	dbConfig, err := loadConfig()
-	if err != nil {
-		log.Fatal("Failed to fetch config")
-		panic(err)
+// newTimeouts converts the configured seconds, keeping the defaults for unset ones
+func newTimeouts(seconds config.MongoTimeouts) Timeouts {
+	timeouts := DefaultTimeouts()
+	if seconds.Read > 0 {
+		timeouts.Read = time.Duration(seconds.Read) * time.Second
 	}
-
-	err = InitDB(dbConfig)
-	if err != nil {
-		log.Fatal("Failed to connect Database")
-		panic(err)
+	if seconds.Write > 0 {
+		timeouts.Write = time.Duration(seconds.Write) * time.Second
 	}
-
+	if seconds.Batch > 0 {
+		timeouts.Batch = time.Duration(seconds.Batch) * time.Second
+	}
+	return timeouts
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/metrics"
+	"context"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// noCollection labels commands that do not target a collection, such as ping
+const noCollection = "none"
+
+// newCommandMonitor records the latency of every MongoDB command by collection and operation
+func newCommandMonitor() *event.CommandMonitor {
+	// Finished events do not carry the command, so the collection is remembered by request ID
+	var collections sync.Map
+
+	finished := func(evt event.CommandFinishedEvent, failed bool) {
+		collection := noCollection
+		if value, ok := collections.LoadAndDelete(evt.RequestID); ok {
+			collection = value.(string)
+		}
+
+		metrics.MongoOperationDuration.Observe(evt.Duration.Seconds(), collection, evt.CommandName)
+		if failed {
+			metrics.MongoOperationErrors.Inc(collection, evt.CommandName)
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			collections.Store(evt.RequestID, commandCollection(evt.CommandName, evt.Command))
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finished(evt.CommandFinishedEvent, false)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finished(evt.CommandFinishedEvent, true)
+		},
+	}
+}
+
+// commandCollection returns the collection a command targets. Most commands name it as
+// the value of their first element, e.g. {find: "properties"}; getMore has a field for it.
+func commandCollection(name string, command bson.Raw) string {
+	if name == "getMore" {
+		if collection, ok := command.Lookup("collection").StringValueOK(); ok {
+			return collection
+		}
+		return noCollection
+	}
+
+	element, err := command.IndexErr(0)
+	if err != nil || !strings.EqualFold(element.Key(), name) {
+		return noCollection
+	}
+	if collection, ok := element.Value().StringValueOK(); ok {
+		return collection
+	}
+	return noCollection
+}
+
+// Results of a cache lookup
+const (
+	cacheHit   = "hit"
+	cacheMiss  = "miss"
+	cacheStale = "stale"
+)
+
+// cacheKeyPrefixes are matched longest first, so a user's recommendations are not
+// counted under the plain user prefix
+var cacheKeyPrefixes = []string{
+	UserRecommendedPrefix,
+	UserFavoritesKeyPrefix,
+	UserKeyPrefix,
+	PropertiesKeyPrefix,
+	PropertyKeyPrefix,
+	AlsoSavedKeyPrefix,
+}
+
+// cacheKeyPrefix maps a cache key onto its prefix, keeping the metric's label set small
+func cacheKeyPrefix(key string) string {
+	for _, prefix := range cacheKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return prefix
+		}
+	}
+	return "other"
+}
+
+func recordCacheLookup(key string, result string) {
+	metrics.CacheRequests.Inc(cacheKeyPrefix(key), result)
+}
+
+func recordEarlyRefresh(key string) {
+	metrics.CacheEarlyRefreshes.Inc(cacheKeyPrefix(key))
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCommandCollection(t *testing.T) {
+	raw := func(doc bson.D) bson.Raw {
+		data, err := bson.Marshal(doc)
+		assert.NoError(t, err)
+		return data
+	}
+
+	assert.Equal(t, "properties", commandCollection("find", raw(bson.D{{Key: "find", Value: "properties"}, {Key: "filter", Value: bson.D{}}})))
+	assert.Equal(t, "users", commandCollection("getMore", raw(bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "users"}})))
+	assert.Equal(t, noCollection, commandCollection("ping", raw(bson.D{{Key: "ping", Value: 1}})))
+}
+
+func TestCacheKeyPrefix(t *testing.T) {
+	assert.Equal(t, PropertyKeyPrefix, cacheKeyPrefix("property:v3:abc"))
+	assert.Equal(t, PropertiesKeyPrefix, cacheKeyPrefix("properties:all:v3:page=1"))
+	assert.Equal(t, UserFavoritesKeyPrefix, cacheKeyPrefix("user:favorites:abc"))
+	assert.Equal(t, UserRecommendedPrefix, cacheKeyPrefix("user:recommended:abc"))
+	assert.Equal(t, UserKeyPrefix, cacheKeyPrefix("user:abc"))
+	assert.Equal(t, "other", cacheKeyPrefix("session:abc"))
+}
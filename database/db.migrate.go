@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection     = "schema_migrations"
+	migrationsLockCollection = "schema_migrations_lock"
+	migrationsLockID         = "lock"
+
+	// A crashed runner's lock is taken over once it expires
+	migrationLockTTL  = 10 * time.Minute
+	migrationLockWait = 2 * time.Minute
+	migrationLockPoll = 500 * time.Millisecond
+)
+
+// Migration is one versioned schema change. Up must be safe to re-run when an earlier
+// attempt failed part way, since only completed migrations are recorded. Down is nil
+// for migrations that cannot be reversed.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationRecord is the schema_migrations document written for each applied migration
+type MigrationRecord struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"appliedAt" json:"appliedAt"`
+}
+
+// Migrator applies and rolls back migrations, holding a lock in MongoDB so that
+// replicas starting together do not run the same migration twice
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+	owner      string
+}
+
+func NewMigrator(db *mongo.Database, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		owner:      primitive.NewObjectID().Hex(),
+	}
+}
+
+// Applied returns the recorded migrations, oldest first
+func (m *Migrator) Applied(ctx context.Context) ([]MigrationRecord, error) {
+	cursor, err := m.db.Collection(migrationsCollection).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []MigrationRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Pending returns the migrations that have not been applied yet, in the order they will run
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pendingMigrations(m.migrations, applied), nil
+}
+
+// CheckCurrent returns an error when migrations are pending, so a server never
+// starts against a schema older than its code expects
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	if unknown := unknownMigrations(m.migrations, applied); len(unknown) > 0 {
+		slog.WarnContext(ctx, "Database has migrations this build does not know about", "count", len(unknown), "latest", unknown[len(unknown)-1].Version)
+	}
+
+	pending := pendingMigrations(m.migrations, applied)
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is out of date: %d pending migrations starting at %d_%s, run the migrate command",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	return nil
+}
+
+// Up applies every pending migration and returns how many ran
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.unlock()
+
+	// Read the state only once the lock is held; another replica may have just finished
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, migration := range pending {
+		slog.InfoContext(ctx, "Applying migration", "version", migration.Version, "name", migration.Name)
+		start := time.Now()
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		_, err := m.db.Collection(migrationsCollection).InsertOne(ctx, MigrationRecord{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name, "duration", time.Since(start).String())
+		applied++
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the most recent applied migrations, newest first, and returns how many ran
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, errors.New("steps must be positive")
+	}
+
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.unlock()
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	rolledBack := 0
+	for i := len(applied) - 1; i >= 0 && rolledBack < steps; i-- {
+		record := applied[i]
+		migration, ok := byVersion[record.Version]
+		if !ok {
+			return rolledBack, fmt.Errorf("migration %d_%s is not known to this build", record.Version, record.Name)
+		}
+		if migration.Down == nil {
+			return rolledBack, fmt.Errorf("migration %d_%s cannot be rolled back", migration.Version, migration.Name)
+		}
+
+		slog.InfoContext(ctx, "Rolling back migration", "version", migration.Version, "name", migration.Name)
+		if err := migration.Down(ctx, m.db); err != nil {
+			return rolledBack, fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		_, err := m.db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": record.Version})
+		if err != nil {
+			return rolledBack, err
+		}
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}
+
+// lock takes the migrations lock, waiting for another runner to finish. The upsert
+// only matches an expired lock, so while one is held it fails with a duplicate key.
+func (m *Migrator) lock(ctx context.Context) error {
+	deadline := time.Now().Add(migrationLockWait)
+	collection := m.db.Collection(migrationsLockCollection)
+
+	for {
+		now := time.Now()
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": migrationsLockID, "expiresAt": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": m.owner, "lockedAt": now, "expiresAt": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		if now.After(deadline) {
+			return errors.New("timed out waiting for the migrations lock")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+}
+
+func (m *Migrator) unlock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := m.db.Collection(migrationsLockCollection).DeleteOne(ctx, bson.M{"_id": migrationsLockID, "owner": m.owner})
+	if err != nil {
+		slog.Error("Error releasing the migrations lock", "error", err)
+	}
+}
+
+// pendingMigrations returns the known migrations missing from the applied records
+func pendingMigrations(migrations []Migration, applied []MigrationRecord) []Migration {
+	done := make(map[int]bool, len(applied))
+	for _, record := range applied {
+		done[record.Version] = true
+	}
+
+	pending := []Migration{}
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+// unknownMigrations returns applied records that have no matching migration,
+// which happens when an older build runs against a newer schema
+func unknownMigrations(migrations []Migration, applied []MigrationRecord) []MigrationRecord {
+	known := make(map[int]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+
+	unknown := []MigrationRecord{}
+	for _, record := range applied {
+		if !known[record.Version] {
+			unknown = append(unknown, record)
+		}
+	}
+
+	return unknown
+}
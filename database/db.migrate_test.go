@@ -0,0 +1,95 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMigrationsAreWellFormed(t *testing.T) {
+	seen := make(map[int]bool)
+	for i, migration := range Migrations {
+		assert.NotZero(t, migration.Version, migration.Name)
+		assert.NotEmpty(t, migration.Name)
+		assert.NotNil(t, migration.Up, migration.Name)
+		// Every migration says how it is undone, even when there is nothing to undo
+		assert.NotNil(t, migration.Down, migration.Name)
+		assert.False(t, seen[migration.Version], "duplicate version %d", migration.Version)
+		seen[migration.Version] = true
+
+		if i > 0 {
+			assert.Greater(t, migration.Version, Migrations[i-1].Version, "migrations must be listed in version order")
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+
+	pending := pendingMigrations(migrations, []MigrationRecord{{Version: 1}, {Version: 3}})
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, 2, pending[0].Version)
+	}
+
+	assert.Empty(t, pendingMigrations(migrations, []MigrationRecord{{Version: 1}, {Version: 2}, {Version: 3}}))
+	assert.Len(t, pendingMigrations(migrations, nil), 3)
+}
+
+func TestUnknownMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "a"}}
+
+	unknown := unknownMigrations(migrations, []MigrationRecord{{Version: 1}, {Version: 7, Name: "future"}})
+	if assert.Len(t, unknown, 1) {
+		assert.Equal(t, 7, unknown[0].Version)
+	}
+}
+
+func TestNewMigratorSortsByVersion(t *testing.T) {
+	migrator := NewMigrator(nil, []Migration{{Version: 3}, {Version: 1}, {Version: 2}})
+
+	var versions []int
+	for _, migration := range migrator.migrations {
+		versions = append(versions, migration.Version)
+	}
+	assert.Equal(t, []int{1, 2, 3}, versions)
+}
+
+func TestPropertyStatusBackfillKeepsOldListingsActive(t *testing.T) {
+	now := time.Now()
+	createdAt := now.AddDate(0, 0, -200)
+
+	// Apply the $set stage to a listing stored before statuses, resolving field paths
+	doc := bson.M{"_id": primitive.NewObjectID(), "title": "Old flat", "createdAt": createdAt, "version": int64(1)}
+	stage := propertyStatusBackfill(now)[0][0]
+	assert.Equal(t, "$set", stage.Key)
+	for field, value := range stage.Value.(bson.M) {
+		if path, ok := value.(string); ok && strings.HasPrefix(path, "$") {
+			value = doc[strings.TrimPrefix(path, "$")]
+		}
+		doc[field] = value
+	}
+	raw, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	var property models.Property
+	require.NoError(t, bson.Unmarshal(raw, &property))
+
+	store := NewMemoryStore()
+	store.properties = append(store.properties, property)
+	repo := NewMemoryPropertyRepository(store)
+
+	archived, err := repo.ArchiveExpired(context.Background(), now.AddDate(0, 0, -90))
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+
+	migrated, err := repo.GetByID(context.Background(), property.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.PropertyActive, migrated.Status)
+	assert.WithinDuration(t, createdAt, *migrated.PublishedAt, time.Millisecond)
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations is the schema history of the service. Append new migrations with the
+// next version; never renumber or edit one that has shipped.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "property_indexes",
+		Up: createIndexes("properties", []mongo.IndexModel{
+			// The same keys and default names the old importer created, so existing indexes are reused
+			{Keys: bson.D{{Key: "city", Value: 1}}},
+			{Keys: bson.D{{Key: "price", Value: 1}}},
+			{Keys: bson.D{{Key: "state", Value: 1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}}},
+			{Keys: bson.D{{Key: "createdBy", Value: 1}}},
+		}),
+		Down: dropIndexes("properties", "city_1", "price_1", "state_1", "type_1", "createdBy_1"),
+	},
+	{
+		Version: 2,
+		Name:    "user_email_unique",
+		Up: createIndexes("users", []mongo.IndexModel{
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("email_unique").SetUnique(true)},
+		}),
+		Down: dropIndexes("users", "email_unique"),
+	},
+	{
+		Version: 3,
+		Name:    "favorite_collection_indexes",
+		Up: createIndexes("favorite_collections", []mongo.IndexModel{
+			{
+				Keys: bson.D{{Key: "userId", Value: 1}, {Key: "isDefault", Value: 1}},
+				Options: options.Index().SetName("userId_default_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"isDefault": true}),
+			},
+			{
+				Keys: bson.D{{Key: "shareToken", Value: 1}},
+				Options: options.Index().SetName("shareToken_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"shareToken": bson.M{"$type": "string"}}),
+			},
+			{Keys: bson.D{{Key: "collaborators", Value: 1}}},
+			{Keys: bson.D{{Key: "items.propertyId", Value: 1}}},
+		}),
+		Down: dropIndexes("favorite_collections", "userId_default_unique", "shareToken_unique", "collaborators_1", "items.propertyId_1"),
+	},
+	{
+		Version: 4,
+		Name:    "notification_indexes",
+		Up: createIndexes("notifications", []mongo.IndexModel{
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		}),
+		Down: dropIndexes("notifications", "userId_1_createdAt_-1"),
+	},
+	{
+		// Runs after the unique default collection index so concurrent upserts cannot create two
+		Version: 5,
+		Name:    "favorites_to_collections",
+		Up:      migrateFavoritesToCollections,
+		// The default collections are kept, so applying the migration again merges
+		// into them without duplicating items
+		Down: restoreFavoritesFromCollections,
+	},
+	{
+		Version: 6,
+		Name:    "user_recommendation_arrays",
+		Up:      backfillUserArrays,
+		// Empty arrays are valid for the previous code too, so there is nothing to undo
+		Down: func(ctx context.Context, db *mongo.Database) error { return nil },
+	},
+	{
+		Version: 7,
+		Name:    "property_versions",
+		Up:      backfillPropertyVersions,
+		// The previous code does not maintain versions, so keeping them would leave stale ETags
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("properties").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"version": ""}})
+			return err
+		},
+	},
+	{
+		Version: 8,
+		Name:    "property_status",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := backfillPropertyStatus(ctx, db); err != nil {
+				return err
+			}
+			_, err := db.Collection("properties").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "status", Value: 1}, {Key: "renewedAt", Value: 1}}},
+			})
+			return err
+		},
+		// The previous code ignores the lifecycle fields, so only the index is dropped
+		Down: dropIndexes("properties", "status_1_renewedAt_1"),
+	},
+}
+
+func createIndexes(collection string, indexes []mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
+
+func dropIndexes(collection string, names ...string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range names {
+			if _, err := db.Collection(collection).Indexes().DropOne(ctx, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrateFavoritesToCollections moves the legacy users.favorites arrays into each user's
+// default collection. Users are only unset once their items are pushed, so a failed run
+// picks up where it stopped.
+func migrateFavoritesToCollections(ctx context.Context, db *mongo.Database) error {
+	repo := &MongoFavoriteRepository{db: db}
+	usersCollection := db.Collection("users")
+
+	cursor, err := usersCollection.Find(ctx,
+		bson.M{"favorites": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"favorites": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var legacy struct {
+			ID        primitive.ObjectID   `bson:"_id"`
+			Favorites []primitive.ObjectID `bson:"favorites"`
+		}
+		if err := cursor.Decode(&legacy); err != nil {
+			slog.ErrorContext(ctx, "Error decoding legacy favorites", "error", err)
+			continue
+		}
+
+		defaultCollection, err := repo.ensureDefaultCollection(ctx, legacy.ID)
+		if err != nil {
+			return err
+		}
+
+		filter := bson.M{"_id": defaultCollection.ID}
+		for _, propertyID := range legacy.Favorites {
+			if _, err := repo.pushCollectionItem(ctx, filter, propertyID, "", legacy.ID); err != nil {
+				return err
+			}
+		}
+
+		_, err = usersCollection.UpdateOne(ctx, bson.M{"_id": legacy.ID}, bson.M{"$unset": bson.M{"favorites": ""}})
+		if err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		slog.InfoContext(ctx, "Migrated favorites into default collections", "users", migrated)
+	}
+
+	return cursor.Err()
+}
+
+// restoreFavoritesFromCollections rebuilds the legacy users.favorites arrays from
+// each user's default collection, for the code that predates collections
+func restoreFavoritesFromCollections(ctx context.Context, db *mongo.Database) error {
+	usersCollection := db.Collection("users")
+
+	cursor, err := db.Collection("favorite_collections").Find(ctx,
+		bson.M{"isDefault": true},
+		options.Find().SetProjection(bson.M{"userId": 1, "items.propertyId": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var defaultCollection models.FavoriteCollection
+		if err := cursor.Decode(&defaultCollection); err != nil {
+			return err
+		}
+
+		favorites := make([]primitive.ObjectID, len(defaultCollection.Items))
+		for i, item := range defaultCollection.Items {
+			favorites[i] = item.PropertyID
+		}
+
+		_, err := usersCollection.UpdateOne(ctx,
+			bson.M{"_id": defaultCollection.UserID},
+			bson.M{"$set": bson.M{"favorites": favorites}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// backfillUserArrays replaces missing or null recommendation and view arrays, which
+// older signups stored and which make $push fail on those users
+func backfillUserArrays(ctx context.Context, db *mongo.Database) error {
+	usersCollection := db.Collection("users")
+
+	for _, field := range []string{"recommendationsReceived", "recentlyViewed"} {
+		_, err := usersCollection.UpdateMany(ctx,
+			bson.M{field: bson.M{"$in": bson.A{nil}}},
+			bson.M{"$set": bson.M{field: bson.A{}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillPropertyVersions gives properties written before versioning version 1,
+// so If-Match has a version to compare against
+func backfillPropertyVersions(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("properties").UpdateMany(ctx,
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"version": int64(1)}},
+	)
+	return err
+}
+
+// backfillPropertyStatus publishes the properties written before listings had a
+// status. They keep their creation time as when they were published, but count as
+// renewed by the migration, so the expiry job does not archive every older listing
+// on its first run after the deploy.
+func backfillPropertyStatus(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("properties").UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		propertyStatusBackfill(time.Now()),
+	)
+	return err
+}
+
+// propertyStatusBackfill is the update backfillPropertyStatus applies, with now as
+// the renewal time
+func propertyStatusBackfill(now time.Time) mongo.Pipeline {
+	return mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"status":      models.PropertyActive,
+		"publishedAt": "$createdAt",
+		"renewedAt":   now,
+	}}}}
+}
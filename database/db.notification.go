@@ -0,0 +1,100 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxInboxPageSize = 100
+
+// MongoNotificationRepository is the NotificationRepository backed by the notifications collection
+type MongoNotificationRepository struct {
+	db       *mongo.Database
+	timeouts Timeouts
+}
+
+func NewMongoNotificationRepository(db *mongo.Database, timeouts Timeouts) *MongoNotificationRepository {
+	return &MongoNotificationRepository{db: db, timeouts: timeouts}
+}
+
+// Create stores a notification in the user's in-app inbox
+func (r *MongoNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Collection("notifications").InsertOne(ctx, notification)
+	return err
+}
+
+// List returns a user's inbox, newest first
+func (r *MongoNotificationRepository) List(ctx context.Context, userID string, unreadOnly bool, limit int64) ([]models.Notification, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if limit <= 0 || limit > maxInboxPageSize {
+		limit = maxInboxPageSize
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	filter := bson.M{"userId": userObjID}
+	if unreadOnly {
+		filter["readAt"] = bson.M{"$exists": false}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+	cursor, err := r.db.Collection("notifications").Find(dbCtx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(dbCtx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(dbCtx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkRead marks one of the user's notifications as read
+func (r *MongoNotificationRepository) MarkRead(ctx context.Context, userID string, notificationID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	notificationObjID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return ErrInvalidNotificationID
+	}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.Collection("notifications").UpdateOne(dbCtx,
+		bson.M{"_id": notificationObjID, "userId": userObjID},
+		bson.M{"$set": bson.M{"readAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MaxRecentlyViewed bounds the recentlyViewed array kept on each user
+	MaxRecentlyViewed = 50
+	// MaxPersonalisedRecommendations is how many ranked listings are returned
+	MaxPersonalisedRecommendations = 20
+
+	favoriteWeight    = 3.0
+	viewWeight        = 1.0
+	candidatePoolSize = 200
+	candidateCities   = 5
+	candidateTypes    = 3
+	priceBandSlack    = 0.2
+)
+
+// GetPersonalised returns the precomputed recommendations for a user,
+// computing them on demand when the batch job has not populated the cache yet
+func (r *MongoRecommendationRepository) GetPersonalised(ctx context.Context, userID string) ([]models.PersonalisedRecommendation, error) {
+	cacheKey := UserRecommendedPrefix + userID
+	var recommendations []models.PersonalisedRecommendation
+	found, err := r.cache.GetFromCache(ctx, cacheKey, &recommendations)
+	if err != nil {
+		slog.WarnContext(ctx, "Error retrieving personalised recommendations from cache", "error", err)
+	}
+
+	if found {
+		return recommendations, nil
+	}
+
+	recommendations, err = r.ComputePersonalised(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.cache.SetInCache(ctx, cacheKey, recommendations, LongTerm)
+	if err != nil {
+		slog.WarnContext(ctx, "Error caching personalised recommendations", "error", err)
+	}
+
+	return recommendations, nil
+}
+
+// ComputePersonalised builds the user's preference profile and ranks unseen listings against it
+func (r *MongoRecommendationRepository) ComputePersonalised(ctx context.Context, userID string) ([]models.PersonalisedRecommendation, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var user models.User
+	err = r.db.Collection("users").FindOne(dbCtx, bson.M{"_id": userObjID}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return r.computeForUser(dbCtx, &user)
+}
+
+// PrecomputePersonalised refreshes the cached recommendations of every
+// user who has favorited or viewed something. It returns the number of users processed.
+func (r *MongoRecommendationRepository) PrecomputePersonalised(ctx context.Context) (int, error) {
+	ctx, cancel := r.timeouts.batch(ctx)
+	defer cancel()
+
+	usersCollection := r.db.Collection("users")
+
+	// Users with saved items may have no views, so every user is visited
+	projection := options.Find().SetProjection(bson.M{"recentlyViewed": 1})
+
+	cursor, err := usersCollection.Find(ctx, bson.M{}, projection)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	processed := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			slog.ErrorContext(ctx, "Error decoding user for recommendations", "error", err)
+			continue
+		}
+
+		dbCtx, cancel := r.timeouts.read(ctx)
+		recommendations, err := r.computeForUser(dbCtx, &user)
+		cancel()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error computing recommendations", "user", user.ID.Hex(), "error", err)
+			continue
+		}
+		// An empty result is stored too, so it replaces an earlier non-empty list
+		err = r.cache.SetInCache(ctx, UserRecommendedPrefix+user.ID.Hex(), recommendations, LongTerm)
+		if err != nil {
+			slog.WarnContext(ctx, "Error caching recommendations", "user", user.ID.Hex(), "error", err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, cursor.Err()
+}
+
+func (r *MongoRecommendationRepository) computeForUser(ctx context.Context, user *models.User) ([]models.PersonalisedRecommendation, error) {
+	propertiesCollection := r.db.Collection("properties")
+
+	favorites, err := favoritePropertyIDs(ctx, r.db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	seedIDs, weights := preferenceSeeds(favorites, user.RecentlyViewed)
+	if len(seedIDs) == 0 {
+		return []models.PersonalisedRecommendation{}, nil
+	}
+
+	// Only published listings shape the profile or are recommended
+	cursor, err := propertiesCollection.Find(ctx, bson.M{"_id": bson.M{"$in": seedIDs}, "status": models.PropertyActive})
+	if err != nil {
+		return nil, err
+	}
+	var seeds []models.Property
+	if err := cursor.All(ctx, &seeds); err != nil {
+		return nil, err
+	}
+
+	profile := BuildPreferenceProfile(seeds, weights)
+	if len(profile.Seeds) == 0 {
+		return []models.PersonalisedRecommendation{}, nil
+	}
+
+	filter := bson.M{
+		"_id": bson.M{"$nin": seedIDs},
+		"$or": []bson.M{
+			{"city": bson.M{"$in": topKeys(profile.Cities, candidateCities)}},
+			{"type": bson.M{"$in": topKeys(profile.Types, candidateTypes)}},
+		},
+		"price":  bson.M{"$gte": profile.MinPrice, "$lte": profile.MaxPrice},
+		"status": models.PropertyActive,
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "rating", Value: -1}}).SetLimit(candidatePoolSize)
+
+	cursor, err = propertiesCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.Property
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	return RankCandidates(profile, candidates, MaxPersonalisedRecommendations), nil
+}
+
+// preferenceSeeds lists the properties a user engaged with, favorites first, and
+// weighs each by how strongly the user engaged with it
+func preferenceSeeds(favorites []primitive.ObjectID, views []models.PropertyView) ([]primitive.ObjectID, map[primitive.ObjectID]float64) {
+	seen := make(map[primitive.ObjectID]bool)
+	weights := make(map[primitive.ObjectID]float64)
+	var seedIDs []primitive.ObjectID
+	for _, id := range favorites {
+		if !seen[id] {
+			seedIDs = append(seedIDs, id)
+		}
+		seen[id] = true
+		weights[id] += favoriteWeight
+	}
+	for _, view := range views {
+		if !seen[view.PropertyID] {
+			seedIDs = append(seedIDs, view.PropertyID)
+		}
+		seen[view.PropertyID] = true
+		weights[view.PropertyID] += viewWeight
+	}
+
+	return seedIDs, weights
+}
+
+// BuildPreferenceProfile aggregates the cities, types, amenities and price band of the
+// seed listings, weighting each by how strongly the user engaged with it
+func BuildPreferenceProfile(seeds []models.Property, weights map[primitive.ObjectID]float64) models.PreferenceProfile {
+	profile := models.PreferenceProfile{
+		Cities:    make(map[string]float64),
+		Types:     make(map[string]float64),
+		Amenities: make(map[string]float64),
+		Seeds:     seeds,
+	}
+
+	var minPrice, maxPrice int64 = math.MaxInt64, 0
+	for _, seed := range seeds {
+		weight := weights[seed.ID]
+		if weight == 0 {
+			weight = viewWeight
+		}
+		profile.Cities[seed.City] += weight
+		profile.Types[seed.Type] += weight
+		for _, amenity := range seed.Amenities {
+			profile.Amenities[amenity] += weight
+		}
+		if seed.Price < minPrice {
+			minPrice = seed.Price
+		}
+		if seed.Price > maxPrice {
+			maxPrice = seed.Price
+		}
+	}
+
+	if len(seeds) > 0 {
+		profile.MinPrice = int64(float64(minPrice) * (1 - priceBandSlack))
+		profile.MaxPrice = int64(float64(maxPrice) * (1 + priceBandSlack))
+	}
+
+	return profile
+}
+
+// RankCandidates scores each candidate against the profile and returns the best ones,
+// each explained by the seed listing it most resembles
+func RankCandidates(profile models.PreferenceProfile, candidates []models.Property, limit int) []models.PersonalisedRecommendation {
+	cityTotal := sumWeights(profile.Cities)
+	typeTotal := sumWeights(profile.Types)
+	amenityTotal := sumWeights(profile.Amenities)
+	midPrice := float64(profile.MinPrice+profile.MaxPrice) / 2
+
+	recommendations := make([]models.PersonalisedRecommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		score := 0.0
+		if cityTotal > 0 {
+			score += 3 * profile.Cities[candidate.City] / cityTotal
+		}
+		if typeTotal > 0 {
+			score += 2 * profile.Types[candidate.Type] / typeTotal
+		}
+		if amenityTotal > 0 && len(candidate.Amenities) > 0 {
+			var overlap float64
+			for _, amenity := range candidate.Amenities {
+				overlap += profile.Amenities[amenity]
+			}
+			score += overlap / amenityTotal
+		}
+		if midPrice > 0 {
+			score += math.Max(0, 1-math.Abs(float64(candidate.Price)-midPrice)/midPrice)
+		}
+		score += candidate.Rating / 10
+
+		recommendations = append(recommendations, models.PersonalisedRecommendation{
+			Property: candidate,
+			Score:    math.Round(score*1000) / 1000,
+			Reason:   "Because you liked " + closestSeed(profile.Seeds, candidate).Title,
+		})
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	return recommendations
+}
+
+func closestSeed(seeds []models.Property, candidate models.Property) models.Property {
+	best := seeds[0]
+	bestScore := -1.0
+	for _, seed := range seeds {
+		similarity := 0.0
+		if seed.City == candidate.City {
+			similarity += 2
+		}
+		if seed.Type == candidate.Type {
+			similarity++
+		}
+		for _, amenity := range candidate.Amenities {
+			for _, seedAmenity := range seed.Amenities {
+				if amenity == seedAmenity {
+					similarity += 0.1
+				}
+			}
+		}
+		if similarity > bestScore {
+			best, bestScore = seed, similarity
+		}
+	}
+	return best
+}
+
+func topKeys(weights map[string]float64, n int) []string {
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if weights[keys[i]] == weights[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return weights[keys[i]] > weights[keys[j]]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+func sumWeights(weights map[string]float64) float64 {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	return total
+}
@@ -1,261 +1,455 @@
 package database
 
 import (
-	"Praiseson6065/Hypergro-assign/middleware"
 	"Praiseson6065/Hypergro-assign/models"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func GetPropertyByID(ctx *gin.Context, propertyID string) (*models.Property, error) {
+// maxUpdateAttempts bounds how often an update without a version is retried when
+// the property changes between reading it and writing
+const maxUpdateAttempts = 3
 
-	cacheKey := PropertyKeyPrefix + propertyID
-	var property models.Property
-	found, err := GetFromCache(ctx, cacheKey, &property)
-	if err != nil {
-		log.Printf("Error retrieving property from cache: %v", err)
-	}
+// MongoPropertyRepository is the PropertyRepository backed by the properties collection
+type MongoPropertyRepository struct {
+	db       *mongo.Database
+	cache    *CacheLayer
+	timeouts Timeouts
+	hooks    []PropertyUpdateHook
+}
 
-	if found {
-		return &property, nil
-	}
+func NewMongoPropertyRepository(db *mongo.Database, cache *CacheLayer, timeouts Timeouts) *MongoPropertyRepository {
+	return &MongoPropertyRepository{db: db, cache: cache, timeouts: timeouts}
+}
 
-	// Not in cache, get from database
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
+// OnUpdated registers a hook that is called after every successful property update
+func (r *MongoPropertyRepository) OnUpdated(hook PropertyUpdateHook) {
+	r.hooks = append(r.hooks, hook)
+}
 
+func (r *MongoPropertyRepository) GetByID(ctx context.Context, propertyID string) (*models.Property, error) {
 	propObjID, err := primitive.ObjectIDFromHex(propertyID)
 	if err != nil {
-		return nil, errors.New("invalid property ID format")
+		return nil, ErrInvalidPropertyID
 	}
 
-	filter := bson.M{"_id": propObjID}
-
-	err = collection.FindOne(dbCtx, filter).Decode(&property)
+	// Read through the cache; concurrent misses share a single database query
+	var property models.Property
+	err = r.cache.GetOrLoad(ctx, PropertyKeyPrefix+propertyID, MediumTerm, &property, func(ctx context.Context) (interface{}, error) {
+		return r.findByID(ctx, propObjID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Store in cache for future requests
-	err = SetInCache(ctx, cacheKey, property, MediumTerm)
-	if err != nil {
-		log.Printf("Error caching property: %v", err)
-	}
-
 	return &property, nil
 }
 
-func GetAllProperties(ctx *gin.Context, filters bson.M) ([]models.Property, error) {
-	// Create a cache key based on the filters
-	filterBytes, err := bson.Marshal(filters)
+func (r *MongoPropertyRepository) GetLatest(ctx context.Context, propertyID string) (*models.Property, error) {
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
 	if err != nil {
-		log.Printf("Error marshaling filters for cache key: %v", err)
-		// Continue without caching
-	} else {
-		// Use the filters in the cache key to ensure uniqueness
-		cacheKey := PropertiesKeyPrefix + string(filterBytes)
-		var properties []models.Property
-		found, err := GetFromCache(ctx, cacheKey, &properties)
-		if err != nil {
-			log.Printf("Error retrieving properties from cache: %v", err)
-		}
-
-		if found {
-			return properties, nil
-		}
+		return nil, ErrInvalidPropertyID
 	}
 
-	// Not in cache or error creating cache key, get from database
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
+	return r.findByID(ctx, propObjID)
+}
 
-	if filters == nil {
-		filters = bson.M{}
+func (r *MongoPropertyRepository) List(ctx context.Context, filter models.PropertyFilter) ([]models.Property, error) {
+	query := propertyFilterQuery(filter)
+	loader := func(ctx context.Context) (interface{}, error) {
+		return r.find(ctx, query)
 	}
 
-	cursor, err := collection.Find(dbCtx, filters)
+	// Create a versioned cache key from the filter's scope and a hash of the filter
+	scope := PropertyListScope(filter)
+	cacheKey, err := r.listCacheKey(ctx, scope, filter)
 	if err != nil {
-		return nil, err
+		slog.WarnContext(ctx, "Error building cache key for properties", "error", err)
+		// Continue without caching
+		return r.find(ctx, query)
 	}
-	defer cursor.Close(dbCtx)
 
 	var properties []models.Property
-	if err := cursor.All(dbCtx, &properties); err != nil {
+	err = r.cache.GetOrLoadPropertyList(ctx, scope, cacheKey, ShortTerm, &properties, loader) // Use a shorter cache time for lists
+	if err != nil {
 		return nil, err
 	}
 
-	// Store in cache for future requests if we successfully created a cache key
-	if filterBytes != nil {
-		cacheKey := PropertiesKeyPrefix + string(filterBytes)
-		err = SetInCache(ctx, cacheKey, properties, ShortTerm) // Use a shorter cache time for lists
-		if err != nil {
-			log.Printf("Error caching properties: %v", err)
-		}
-	}
-
 	return properties, nil
 }
 
-func GetAllPropertiesByUser(ctx *gin.Context) ([]models.Property, error) {
-	userID := middleware.GetUserID(ctx)
+func (r *MongoPropertyRepository) ListByOwner(ctx context.Context, ownerID string) ([]models.Property, error) {
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	filter := bson.M{"createdBy": userObjID}
+	loader := func(ctx context.Context) (interface{}, error) {
+		return r.find(ctx, filter)
+	}
 
-	// Try to get from cache first
-	cacheKey := PropertiesKeyPrefix + "user:" + userID
-	var properties []models.Property
-	found, err := GetFromCache(ctx, cacheKey, &properties)
+	scope := OwnerListScope(ownerID)
+	cacheKey, err := r.cache.PropertyListKey(ctx, scope, "list")
 	if err != nil {
-		log.Printf("Error retrieving user properties from cache: %v", err)
+		slog.WarnContext(ctx, "Error building cache key for user properties", "error", err)
+		return r.find(ctx, filter)
 	}
 
-	if found {
-		return properties, nil
+	var properties []models.Property
+	err = r.cache.GetOrLoadPropertyList(ctx, scope, cacheKey, ShortTerm, &properties, loader)
+	if err != nil {
+		return nil, err
 	}
 
-	// Not in cache, get from database
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	return properties, nil
+}
+
+func (r *MongoPropertyRepository) findByID(ctx context.Context, propObjID primitive.ObjectID) (*models.Property, error) {
+	collection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.read(ctx)
 	defer cancel()
 
-	userObjID, err := primitive.ObjectIDFromHex(userID)
+	var property models.Property
+	err := collection.FindOne(dbCtx, bson.M{"_id": propObjID}).Decode(&property)
 	if err != nil {
-		return nil, errors.New("invalid user ID format")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPropertyNotFound
+		}
+		return nil, err
 	}
 
-	filter := bson.M{"createdBy": userObjID}
+	return &property, nil
+}
 
-	cursor, err := collection.Find(dbCtx, filter)
+func (r *MongoPropertyRepository) find(ctx context.Context, filters bson.M) ([]models.Property, error) {
+	collection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	cursor, err := collection.Find(dbCtx, filters)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(dbCtx)
 
+	properties := []models.Property{}
 	if err := cursor.All(dbCtx, &properties); err != nil {
 		return nil, err
 	}
 
-	// Store in cache for future requests
-	err = SetInCache(ctx, cacheKey, properties, ShortTerm)
-	if err != nil {
-		log.Printf("Error caching user properties: %v", err)
-	}
-
 	return properties, nil
 }
 
-func CreateAProperty(ctx *gin.Context, property *models.Property) (*models.Property, error) {
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+func (r *MongoPropertyRepository) Create(ctx context.Context, property *models.Property) (*models.Property, error) {
+	collection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.write(ctx)
 	defer cancel()
 
-	property.CreatedAt = time.Now()
+	initProperty(property, time.Now())
 
 	_, err := collection.InsertOne(dbCtx, property)
 	if err != nil {
 		return nil, err
 	}
 
-	// Invalidate any cached property lists since we added a new property
-	err = DeleteByPattern(ctx, PropertiesKeyPrefix+"*")
-	if err != nil {
-		log.Printf("Error clearing properties cache: %v", err)
-	}
+	// Invalidate the cached lists the new property could appear in
+	r.cache.InvalidatePropertyLists(ctx, *property)
 
 	return property, nil
 
 }
 
-func UpdateAProperty(ctx *gin.Context, propertyUpdates map[string]interface{}, propertyID primitive.ObjectID) (*models.Property, error) {
-	userID := middleware.GetUserID(ctx)
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+func (r *MongoPropertyRepository) Update(ctx context.Context, ownerID string, propertyID string, version int64, propertyUpdate PropertyUpdate) (*models.Property, error) {
+	collection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.write(ctx)
 	defer cancel()
 
-	userObjID, err := primitive.ObjectIDFromHex(userID)
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
 	if err != nil {
-		return nil, errors.New("invalid user ID format")
+		return nil, ErrInvalidPropertyID
 	}
 
-	filter := bson.M{
-		"_id":       propertyID,
-		"createdBy": userObjID,
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidUserID
 	}
 
-	count, err := collection.CountDocuments(dbCtx, filter)
-	if err != nil {
-		return nil, err
+	update := propertyUpdateDocument(propertyUpdate)
+	update["$inc"] = bson.M{"version": 1}
+
+	for attempt := 1; ; attempt++ {
+		// Read the current state uncached, so update hooks can see what changed
+		previousProperty, err := r.findByID(ctx, propObjID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The write is pinned to the version just read, and the expected values are
+		// part of the filter, so checking them and writing is a single atomic
+		// operation and previousProperty is exactly the state it replaced
+		writeVersion := version
+		if writeVersion == AnyVersion {
+			writeVersion = previousProperty.Version
+		}
+		filter := propertyWriteFilter(propObjID, userObjID, writeVersion)
+		for key, value := range propertyUpdate.Expect {
+			filter[key] = value
+		}
+
+		var updatedProperty models.Property
+		err = collection.FindOneAndUpdate(dbCtx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updatedProperty)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, err
+			}
+			err = r.unmatched(dbCtx, propObjID, userObjID, writeVersion)
+			// Without a version from the client, another write in between only means
+			// reading the property again
+			if version == AnyVersion && errors.Is(err, ErrVersionMismatch) && attempt < maxUpdateAttempts {
+				continue
+			}
+			return nil, err
+		}
+
+		// Clear the cached copy and the lists the property was or now is in
+		r.cache.ClearPropertyCache(ctx, propertyID)
+		r.cache.InvalidatePropertyLists(ctx, *previousProperty, updatedProperty)
+
+		for _, hook := range r.hooks {
+			hook(*previousProperty, updatedProperty)
+		}
+
+		return &updatedProperty, nil
 	}
-	if count == 0 {
-		return nil, errors.New("property not found or you don't have permission to update it")
+}
+
+// initProperty sets the server-owned fields of a new property. Listings are
+// published unless they are created as drafts.
+func initProperty(property *models.Property, now time.Time) {
+	if property.ID.IsZero() {
+		property.ID = primitive.NewObjectID()
 	}
+	property.CreatedAt = now
+	property.Version = 1
 
-	// Only update fields that were provided in the request
-	update := bson.M{
-		"$set": propertyUpdates,
+	if property.Status == "" {
+		property.Status = models.PropertyActive
+	}
+	if property.Status == models.PropertyActive {
+		property.PublishedAt = &now
+		property.RenewedAt = &now
 	}
+}
+
+// ArchiveExpired archives the active listings last renewed before renewedBefore.
+// The expired listings are read first so their cached copies and lists can be
+// cleared; the status and renewal are checked again by the update, so a listing
+// renewed in between stays active.
+func (r *MongoPropertyRepository) ArchiveExpired(ctx context.Context, renewedBefore time.Time) (int64, error) {
+	collection := r.db.Collection("properties")
+	filter := expiredFilter(renewedBefore)
 
-	_, err = collection.UpdateOne(dbCtx, filter, update)
+	expired, err := r.find(ctx, filter)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
 	}
 
-	// Fetch the updated property to return
-	var updatedProperty models.Property
-	err = collection.FindOne(dbCtx, bson.M{"_id": propertyID}).Decode(&updatedProperty)
+	ids := make([]primitive.ObjectID, len(expired))
+	for i, property := range expired {
+		ids[i] = property.ID
+	}
+	filter["_id"] = bson.M{"$in": ids}
+
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := collection.UpdateMany(dbCtx, filter, bson.M{
+		"$set": bson.M{"status": models.PropertyArchived, "archivedAt": time.Now()},
+		"$inc": bson.M{"version": 1},
+	})
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	for _, property := range expired {
+		r.cache.ClearPropertyCache(ctx, property.ID.Hex())
 	}
+	r.cache.InvalidatePropertyLists(ctx, expired...)
+
+	return result.ModifiedCount, nil
+}
 
-	// Clear the cache for this property
-	ClearPropertyCache(ctx, propertyID.Hex())
+// expiredFilter matches active listings last renewed before renewedBefore
+func expiredFilter(renewedBefore time.Time) bson.M {
+	return bson.M{
+		"status":    models.PropertyActive,
+		"renewedAt": bson.M{"$lt": renewedBefore},
+	}
+}
 
-	return &updatedProperty, nil
+// propertyUpdateDocument translates an update into MongoDB update operators
+func propertyUpdateDocument(propertyUpdate PropertyUpdate) bson.M {
+	update := bson.M{}
+	if len(propertyUpdate.Set) > 0 {
+		update["$set"] = propertyUpdate.Set
+	}
+	if len(propertyUpdate.Unset) > 0 {
+		unset := bson.M{}
+		for _, field := range propertyUpdate.Unset {
+			unset[field] = ""
+		}
+		update["$unset"] = unset
+	}
+	if len(propertyUpdate.AddToSet) > 0 {
+		addToSet := bson.M{}
+		for field, values := range propertyUpdate.AddToSet {
+			addToSet[field] = bson.M{"$each": values}
+		}
+		update["$addToSet"] = addToSet
+	}
+	if len(propertyUpdate.Pull) > 0 {
+		pull := bson.M{}
+		for field, values := range propertyUpdate.Pull {
+			pull[field] = bson.M{"$in": values}
+		}
+		update["$pull"] = pull
+	}
+	return update
 }
 
-func DeleteAProperty(ctx *gin.Context, propertyID string, userID string) error {
-	db := GetMongoDB()
-	collection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+func (r *MongoPropertyRepository) Delete(ctx context.Context, ownerID string, propertyID string, version int64) error {
+	collection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.write(ctx)
 	defer cancel()
 
 	propObjID, err := primitive.ObjectIDFromHex(propertyID)
 	if err != nil {
-		return errors.New("invalid property ID format")
+		return ErrInvalidPropertyID
 	}
 
-	userObjID, err := primitive.ObjectIDFromHex(userID)
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
 	if err != nil {
-		return errors.New("invalid user ID format")
+		return ErrInvalidUserID
 	}
 
+	var deletedProperty models.Property
+	err = collection.FindOneAndDelete(dbCtx, propertyWriteFilter(propObjID, userObjID, version)).Decode(&deletedProperty)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return r.unmatched(dbCtx, propObjID, userObjID, version)
+		}
+		return err
+	}
+
+	// Clear the cache for this property and any lists it was in
+	r.cache.ClearPropertyCache(ctx, propertyID)
+	r.cache.InvalidatePropertyLists(ctx, deletedProperty)
+
+	return nil
+}
+
+// propertyWriteFilter matches a property owned by the user at the given version
+func propertyWriteFilter(propObjID primitive.ObjectID, userObjID primitive.ObjectID, version int64) bson.M {
 	filter := bson.M{
 		"_id":       propObjID,
 		"createdBy": userObjID,
 	}
+	if version != AnyVersion {
+		filter["version"] = version
+	}
+	return filter
+}
 
-	result, err := collection.DeleteOne(dbCtx, filter)
+// unmatched explains why a write filter matched no property
+func (r *MongoPropertyRepository) unmatched(ctx context.Context, propObjID primitive.ObjectID, userObjID primitive.ObjectID, version int64) error {
+	var stored struct {
+		CreatedBy primitive.ObjectID `bson:"createdBy"`
+		Version   int64              `bson:"version"`
+	}
+	err := r.db.Collection("properties").FindOne(ctx, bson.M{"_id": propObjID},
+		options.FindOne().SetProjection(bson.M{"createdBy": 1, "version": 1}),
+	).Decode(&stored)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrPropertyNotFound
+		}
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return errors.New("property not found or you don't have permission to delete it")
+	switch {
+	case stored.CreatedBy != userObjID:
+		return ErrPropertyNotOwned
+	case version != AnyVersion && stored.Version != version:
+		return ErrVersionMismatch
+	default:
+		// Only the update's expected values can have failed to match
+		return ErrPropertyChanged
 	}
+}
 
-	// Clear the cache for this property and any property lists
-	ClearPropertyCache(ctx, propertyID)
+// listCacheKey hashes the filter into a stable suffix; struct fields are encoded in
+// declaration order, so equal filters always produce the same key
+func (r *MongoPropertyRepository) listCacheKey(ctx context.Context, scope string, filter models.PropertyFilter) (string, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
 
-	return nil
+	sum := sha1.Sum(filterJSON)
+	return r.cache.PropertyListKey(ctx, scope, hex.EncodeToString(sum[:]))
+}
+
+// propertyFilterQuery translates a listing filter into a MongoDB query
+func propertyFilterQuery(filter models.PropertyFilter) bson.M {
+	query := bson.M{}
+
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.City != "" {
+		query["city"] = filter.City
+	}
+	if filter.State != "" {
+		query["state"] = filter.State
+	}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		priceFilter := bson.M{}
+		if filter.MinPrice != nil {
+			priceFilter["$gte"] = *filter.MinPrice
+		}
+		if filter.MaxPrice != nil {
+			priceFilter["$lte"] = *filter.MaxPrice
+		}
+		query["price"] = priceFilter
+	}
+	if filter.Bedrooms != nil {
+		query["bedrooms"] = *filter.Bedrooms
+	}
+	if filter.Bathrooms != nil {
+		query["bathrooms"] = *filter.Bathrooms
+	}
+	if filter.Furnished != "" {
+		query["furnished"] = filter.Furnished
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	return query
 }
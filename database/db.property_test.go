@@ -0,0 +1,122 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPropertyUpdateDocument(t *testing.T) {
+	update := propertyUpdateDocument(PropertyUpdate{
+		Set:      map[string]interface{}{"price": int64(100)},
+		Unset:    []string{"furnished"},
+		AddToSet: map[string][]string{"amenities": {"pool"}},
+		Pull:     map[string][]string{"tags": {"noisy", "old"}},
+	})
+
+	assert.Equal(t, bson.M{
+		"$set":      map[string]interface{}{"price": int64(100)},
+		"$unset":    bson.M{"furnished": ""},
+		"$addToSet": bson.M{"amenities": bson.M{"$each": []string{"pool"}}},
+		"$pull":     bson.M{"tags": bson.M{"$in": []string{"noisy", "old"}}},
+	}, update)
+
+	assert.Equal(t, bson.M{"$set": map[string]interface{}{"title": "x"}}, propertyUpdateDocument(PropertyUpdate{Set: map[string]interface{}{"title": "x"}}))
+}
+
+func TestPropertyWriteFilter(t *testing.T) {
+	propertyID := primitive.NewObjectID()
+	ownerID := primitive.NewObjectID()
+
+	assert.Equal(t, bson.M{"_id": propertyID, "createdBy": ownerID, "version": int64(4)}, propertyWriteFilter(propertyID, ownerID, 4))
+	assert.Equal(t, bson.M{"_id": propertyID, "createdBy": ownerID}, propertyWriteFilter(propertyID, ownerID, AnyVersion))
+}
+
+func TestInitProperty(t *testing.T) {
+	now := time.Now()
+
+	published := models.Property{}
+	initProperty(&published, now)
+	assert.False(t, published.ID.IsZero())
+	assert.Equal(t, models.PropertyActive, published.Status)
+	assert.Equal(t, &now, published.PublishedAt)
+	assert.Equal(t, &now, published.RenewedAt)
+
+	draft := models.Property{Status: models.PropertyDraft}
+	initProperty(&draft, now)
+	assert.Equal(t, models.PropertyDraft, draft.Status)
+	assert.Nil(t, draft.PublishedAt)
+	assert.Nil(t, draft.RenewedAt)
+}
+
+func TestExpiredFilter(t *testing.T) {
+	cutoff := time.Now()
+	assert.Equal(t, bson.M{"status": "active", "renewedAt": bson.M{"$lt": cutoff}}, expiredFilter(cutoff))
+	assert.Equal(t, bson.M{"city": "Pune", "status": "active"}, propertyFilterQuery(models.PropertyFilter{City: "Pune", Status: "active"}))
+}
+
+func TestApplyPropertyUpdate(t *testing.T) {
+	// Expect is left to checkExpected, so it is not applied here
+	updated, err := applyPropertyUpdate(models.Property{Price: 100, Tags: []string{"new"}}, PropertyUpdate{
+		Set:    map[string]interface{}{"price": int64(200)},
+		Pull:   map[string][]string{"tags": {"new"}},
+		Expect: map[string]interface{}{"price": int64(1)},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 200, updated.Price)
+	assert.Empty(t, updated.Tags)
+}
+
+func TestMemoryPropertyUpdate(t *testing.T) {
+	repo := NewMemoryPropertyRepository(NewMemoryStore())
+	owner := primitive.NewObjectID()
+	created, err := repo.Create(context.Background(), &models.Property{
+		Title: "Flat", Price: 100, Furnished: "Semi", Amenities: []string{"lift", "gym"}, CreatedBy: owner,
+	})
+	require.NoError(t, err)
+	id := created.ID.Hex()
+	assert.EqualValues(t, 1, created.Version)
+
+	updated, err := repo.Update(context.Background(), owner.Hex(), id, 1, PropertyUpdate{
+		Unset:    []string{"furnished"},
+		AddToSet: map[string][]string{"amenities": {"gym", "pool"}},
+		Pull:     map[string][]string{"tags": {"absent"}},
+		Expect:   map[string]interface{}{"price": int64(100), "amenities": bson.A{"lift", "gym"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Furnished)
+	assert.Equal(t, []string{"lift", "gym", "pool"}, updated.Amenities)
+	assert.EqualValues(t, 2, updated.Version)
+
+	updated, err = repo.Update(context.Background(), owner.Hex(), id, AnyVersion, PropertyUpdate{
+		Pull: map[string][]string{"amenities": {"lift"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gym", "pool"}, updated.Amenities)
+	assert.EqualValues(t, 3, updated.Version)
+
+	// Writes to an older version are refused
+	_, err = repo.Update(context.Background(), owner.Hex(), id, 2, PropertyUpdate{Set: map[string]interface{}{"title": "Changed"}})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorIs(t, repo.Delete(context.Background(), owner.Hex(), id, 2), ErrVersionMismatch)
+
+	// A stale expectation leaves the property alone
+	_, err = repo.Update(context.Background(), owner.Hex(), id, 3, PropertyUpdate{
+		Set:    map[string]interface{}{"title": "Changed"},
+		Expect: map[string]interface{}{"price": int64(99)},
+	})
+	assert.ErrorIs(t, err, ErrPropertyChanged)
+
+	current, err := repo.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Flat", current.Title)
+	assert.EqualValues(t, 3, current.Version)
+
+	assert.NoError(t, repo.Delete(context.Background(), owner.Hex(), id, 3))
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoRecommendationRepository is the RecommendationRepository backed by the users,
+// properties and property_neighbours collections
+type MongoRecommendationRepository struct {
+	db       *mongo.Database
+	cache    *CacheLayer
+	timeouts Timeouts
+}
+
+func NewMongoRecommendationRepository(db *mongo.Database, cache *CacheLayer, timeouts Timeouts) *MongoRecommendationRepository {
+	return &MongoRecommendationRepository{db: db, cache: cache, timeouts: timeouts}
+}
+
+// ListReceived retrieves recommendations received by a user
+func (r *MongoRecommendationRepository) ListReceived(ctx context.Context, userID string) ([]map[string]interface{}, error) {
+	// Try to get from cache first
+	cacheKey := "user:recommendations:" + userID
+	var recommendations []map[string]interface{}
+	found, err := r.cache.GetFromCache(ctx, cacheKey, &recommendations)
+	if err != nil {
+		slog.WarnContext(ctx, "Error retrieving recommendations from cache", "error", err)
+	}
+
+	if found {
+		return recommendations, nil
+	}
+
+	// Not in cache, get from database
+	usersCollection := r.db.Collection("users")
+	propertiesCollection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	// Convert userID to ObjectID
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	// Find the user to get their recommendations
+	var user models.User
+	err = usersCollection.FindOne(dbCtx, bson.M{"_id": userObjID}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// If no recommendations, return empty slice
+	if len(user.RecommendationsReceived) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	// Gather all property IDs and recommender IDs for lookup
+	var propertyIDs []primitive.ObjectID
+	var recommenderIDs []primitive.ObjectID
+	for _, rec := range user.RecommendationsReceived {
+		propertyIDs = append(propertyIDs, rec.PropertyID)
+		recommenderIDs = append(recommenderIDs, rec.RecommendedBy)
+	}
+
+	// Get properties
+	propertiesCursor, err := propertiesCollection.Find(dbCtx, bson.M{"_id": bson.M{"$in": propertyIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer propertiesCursor.Close(dbCtx)
+
+	var properties []models.Property
+	if err := propertiesCursor.All(dbCtx, &properties); err != nil {
+		return nil, err
+	}
+
+	// Get recommenders
+	recommendersCursor, err := usersCollection.Find(dbCtx, bson.M{"_id": bson.M{"$in": recommenderIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer recommendersCursor.Close(dbCtx)
+
+	var recommenders []models.User
+	if err := recommendersCursor.All(dbCtx, &recommenders); err != nil {
+		return nil, err
+	}
+
+	// Create property and recommender maps for easy lookup
+	propertyMap := make(map[string]models.Property)
+	for _, property := range properties {
+		propertyMap[property.ID.Hex()] = property
+	}
+
+	recommenderMap := make(map[string]models.User)
+	for _, recommender := range recommenders {
+		recommenderMap[recommender.ID.Hex()] = recommender
+	}
+
+	// Build detailed recommendations
+	var detailedRecommendations []map[string]interface{}
+	for _, rec := range user.RecommendationsReceived {
+		property, propertyExists := propertyMap[rec.PropertyID.Hex()]
+		recommender, recommenderExists := recommenderMap[rec.RecommendedBy.Hex()]
+
+		if propertyExists && recommenderExists {
+			detailedRecommendation := map[string]interface{}{
+				"property":      property,
+				"recommendedBy": map[string]interface{}{"id": recommender.ID, "name": recommender.Name, "email": recommender.Email},
+				"recommendedAt": rec.RecommendedAt,
+			}
+			detailedRecommendations = append(detailedRecommendations, detailedRecommendation)
+		}
+	}
+
+	// Store in cache for future requests
+	err = r.cache.SetInCache(ctx, cacheKey, detailedRecommendations, MediumTerm)
+	if err != nil {
+		slog.WarnContext(ctx, "Error caching recommendations", "error", err)
+	}
+
+	return detailedRecommendations, nil
+}
+
+// Recommend adds a property recommendation to a user
+func (r *MongoRecommendationRepository) Recommend(ctx context.Context, fromUserID, toUserID, propertyID string) error {
+	usersCollection := r.db.Collection("users")
+	propertiesCollection := r.db.Collection("properties")
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	// Convert IDs to ObjectIDs
+	fromUserObjID, err := primitive.ObjectIDFromHex(fromUserID)
+	if err != nil {
+		return ErrInvalidRecommenderID
+	}
+
+	toUserObjID, err := primitive.ObjectIDFromHex(toUserID)
+	if err != nil {
+		return ErrInvalidRecipientID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	// Check if property exists
+	count, err := propertiesCollection.CountDocuments(dbCtx, bson.M{"_id": propObjID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPropertyNotFound
+	}
+
+	// Check if recipient user exists
+	count, err = usersCollection.CountDocuments(dbCtx, bson.M{"_id": toUserObjID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrRecipientNotFound
+	}
+
+	// Create recommendation
+	recommendation := models.Recommendation{
+		PropertyID:    propObjID,
+		RecommendedBy: fromUserObjID,
+		RecommendedAt: time.Now(),
+	}
+
+	// Add recommendation to recipient's recommendations
+	update := bson.M{
+		"$push": bson.M{
+			"recommendationsReceived": recommendation,
+		},
+	}
+
+	_, err = usersCollection.UpdateOne(dbCtx, bson.M{"_id": toUserObjID}, update)
+	if err != nil {
+		return err
+	}
+
+	// Clear the recommendations cache for this user since they've received a new recommendation
+	err = r.cache.DeleteFromCache(ctx, "user:recommendations:"+toUserID)
+	if err != nil {
+		slog.WarnContext(ctx, "Error clearing recommendations cache", "error", err)
+	}
+
+	return nil
+}
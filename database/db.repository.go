@@ -0,0 +1,133 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PropertyUpdateHook receives a property as it was before and after an update.
+// Hooks run on the request path, so they must hand slow work off elsewhere.
+type PropertyUpdateHook func(before models.Property, after models.Property)
+
+// AnyVersion makes a property update or delete apply whatever the property's version
+const AnyVersion int64 = 0
+
+// PropertyUpdate is an atomic change to a property. Fields are named by their
+// BSON keys, and each field may appear under only one operation.
+type PropertyUpdate struct {
+	// Set replaces the value of each field
+	Set map[string]interface{}
+	// Unset removes each field, leaving its zero value
+	Unset []string
+	// AddToSet adds each value to an array field unless it is already there
+	AddToSet map[string][]string
+	// Pull removes every occurrence of each value from an array field
+	Pull map[string][]string
+	// Expect holds values the stored fields must still have for the update to
+	// apply; otherwise Update returns ErrPropertyChanged
+	Expect map[string]interface{}
+}
+
+// IsEmpty reports whether the update changes nothing
+func (u PropertyUpdate) IsEmpty() bool {
+	return len(u.Set) == 0 && len(u.Unset) == 0 && len(u.AddToSet) == 0 && len(u.Pull) == 0
+}
+
+// PropertyRepository stores property listings
+type PropertyRepository interface {
+	GetByID(ctx context.Context, propertyID string) (*models.Property, error)
+	// GetLatest reads a property past any cache, for writes that act on its
+	// current state
+	GetLatest(ctx context.Context, propertyID string) (*models.Property, error)
+	List(ctx context.Context, filter models.PropertyFilter) ([]models.Property, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]models.Property, error)
+	Create(ctx context.Context, property *models.Property) (*models.Property, error)
+	// Update applies the update to a property owned by ownerID if it is still at
+	// version, and increments its version
+	Update(ctx context.Context, ownerID string, propertyID string, version int64, update PropertyUpdate) (*models.Property, error)
+	// Delete removes a property owned by ownerID if it is still at version
+	Delete(ctx context.Context, ownerID string, propertyID string, version int64) error
+	// ArchiveExpired archives the active listings last renewed before renewedBefore
+	// and returns how many it archived
+	ArchiveExpired(ctx context.Context, renewedBefore time.Time) (int64, error)
+	// OnUpdated registers a hook that is called after every successful update
+	OnUpdated(hook PropertyUpdateHook)
+}
+
+// UserRepository stores accounts, their browsing history and alert settings
+type UserRepository interface {
+	// FirstOrCreate returns the ID of the user with the same email, creating the user if needed
+	FirstOrCreate(ctx context.Context, user *models.User) (string, error)
+	// GetCredentials returns the password hash and ID of the user with the email
+	GetCredentials(ctx context.Context, email string) (string, string, error)
+	GetByID(ctx context.Context, userID string) (*models.User, error)
+	RecordPropertyView(ctx context.Context, userID string, propertyID string) error
+	GetNotificationPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error)
+	UpdateNotificationPreferences(ctx context.Context, userID string, preferences models.NotificationPreferences) error
+}
+
+// FavoriteRepository stores users' shortlists: the default favorites list, named
+// collections, and everything shared with collaborators
+type FavoriteRepository interface {
+	ListFavorites(ctx context.Context, userID string) ([]models.Property, error)
+	AddFavorite(ctx context.Context, userID string, propertyID string) error
+	RemoveFavorite(ctx context.Context, userID string, propertyID string) error
+	// FindUsersWhoSaved returns every user with the property in one of their collections
+	FindUsersWhoSaved(ctx context.Context, propertyID primitive.ObjectID) ([]models.User, error)
+
+	ListCollections(ctx context.Context, userID string) ([]models.FavoriteCollection, error)
+	CreateCollection(ctx context.Context, userID string, name string) (*models.FavoriteCollection, error)
+	GetCollection(ctx context.Context, ownerID string, actorID string, collectionID string) (*models.FavoriteCollection, error)
+	GetCollectionItems(ctx context.Context, favoriteCollection *models.FavoriteCollection) ([]models.FavoriteItemDetail, error)
+	RenameCollection(ctx context.Context, userID string, collectionID string, name string) (*models.FavoriteCollection, error)
+	DeleteCollection(ctx context.Context, userID string, collectionID string) error
+	AddCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, note string) error
+	UpdateCollectionItemNote(ctx context.Context, userID string, collectionID string, propertyID string, note string) error
+	RemoveCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string) error
+
+	CreateShareLink(ctx context.Context, ownerID string, collectionID string) (string, error)
+	RevokeShareLink(ctx context.Context, ownerID string, collectionID string) error
+	GetSharedCollection(ctx context.Context, token string) (*models.FavoriteCollection, error)
+	AddCollaborator(ctx context.Context, ownerID string, collectionID string, collaboratorID string) error
+	RemoveCollaborator(ctx context.Context, ownerID string, actorID string, collectionID string, collaboratorID string) error
+	ListSharedWithUser(ctx context.Context, userID string) ([]models.FavoriteCollection, error)
+	AddItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, text string) (*models.ItemComment, error)
+	DeleteItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, commentID string) error
+	SetItemReaction(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, value string) error
+}
+
+// RecommendationRepository stores user-to-user recommendations and the
+// personalised and also-saved suggestions derived from activity
+type RecommendationRepository interface {
+	ListReceived(ctx context.Context, userID string) ([]map[string]interface{}, error)
+	Recommend(ctx context.Context, fromUserID string, toUserID string, propertyID string) error
+	GetPersonalised(ctx context.Context, userID string) ([]models.PersonalisedRecommendation, error)
+	// PrecomputePersonalised refreshes every user's cached recommendations and returns how many were stored
+	PrecomputePersonalised(ctx context.Context) (int, error)
+	GetAlsoSaved(ctx context.Context, propertyID string) ([]models.AlsoSavedProperty, error)
+	// ComputeCooccurrence rebuilds the also-saved neighbours and returns how many properties have some
+	ComputeCooccurrence(ctx context.Context, minSupport int, topN int) (int, error)
+}
+
+// NotificationRepository stores the in-app notification inbox
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	List(ctx context.Context, userID string, unreadOnly bool, limit int64) ([]models.Notification, error)
+	MarkRead(ctx context.Context, userID string, notificationID string) error
+}
+
+var (
+	_ PropertyRepository       = (*MongoPropertyRepository)(nil)
+	_ PropertyRepository       = (*MemoryPropertyRepository)(nil)
+	_ UserRepository           = (*MongoUserRepository)(nil)
+	_ UserRepository           = (*MemoryUserRepository)(nil)
+	_ FavoriteRepository       = (*MongoFavoriteRepository)(nil)
+	_ FavoriteRepository       = (*MemoryFavoriteRepository)(nil)
+	_ RecommendationRepository = (*MongoRecommendationRepository)(nil)
+	_ RecommendationRepository = (*MemoryRecommendationRepository)(nil)
+	_ NotificationRepository   = (*MongoNotificationRepository)(nil)
+	_ NotificationRepository   = (*MemoryNotificationRepository)(nil)
+)
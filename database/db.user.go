@@ -4,28 +4,56 @@ import (
 	"Praiseson6065/Hypergro-assign/models"
 	"context"
 	"errors"
-	"log"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func FirstOrCreateUser(ctx *gin.Context, user *models.User) (string, error) {
-	db := GetMongoDB()
-	collection := db.Collection("users")
+// MongoUserRepository is the UserRepository backed by the users collection
+type MongoUserRepository struct {
+	db       *mongo.Database
+	cache    *CacheLayer
+	timeouts Timeouts
+}
+
+func NewMongoUserRepository(db *mongo.Database, cache *CacheLayer, timeouts Timeouts) *MongoUserRepository {
+	return &MongoUserRepository{db: db, cache: cache, timeouts: timeouts}
+}
 
-	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *MongoUserRepository) FirstOrCreate(ctx context.Context, user *models.User) (string, error) {
+	collection := r.db.Collection("users")
+
+	dbCtx, cancel := r.timeouts.write(ctx)
 	defer cancel()
 
 	existingUser := &models.User{}
 	err := collection.FindOne(dbCtx, bson.M{"email": user.Email}).Decode(existingUser)
 
-	if err != nil && err.Error() == "mongo: no documents in result" {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if user.ID.IsZero() {
+			user.ID = primitive.NewObjectID()
+		}
 		user.CreatedAt = time.Now()
+		// Store empty arrays rather than null so later $push updates apply
+		if user.RecommendationsReceived == nil {
+			user.RecommendationsReceived = []models.Recommendation{}
+		}
+		if user.RecentlyViewed == nil {
+			user.RecentlyViewed = []models.PropertyView{}
+		}
 
 		insertResult, err := collection.InsertOne(dbCtx, user)
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent signup with the same email won the unique index
+			err = collection.FindOne(dbCtx, bson.M{"email": user.Email}).Decode(existingUser)
+			if err != nil {
+				return "", err
+			}
+			return existingUser.ID.Hex(), nil
+		}
 		if err != nil {
 			return "", err
 		}
@@ -42,18 +70,17 @@ func FirstOrCreateUser(ctx *gin.Context, user *models.User) (string, error) {
 	return existingUser.ID.Hex(), nil
 }
 
-func GetPasswordByMail(ctx *gin.Context, email string) (string, string, error) {
-	db := GetMongoDB()
-	collection := db.Collection("users")
+func (r *MongoUserRepository) GetCredentials(ctx context.Context, email string) (string, string, error) {
+	collection := r.db.Collection("users")
 
-	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	dbCtx, cancel := r.timeouts.read(ctx)
 	defer cancel()
 
 	var user models.User
 	err := collection.FindOne(dbCtx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
-		if err.Error() == "mongo: no documents in result" {
-			return "", "", errors.New("user not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", "", ErrUserNotFound
 		}
 		return "", "", err
 	}
@@ -61,24 +88,23 @@ func GetPasswordByMail(ctx *gin.Context, email string) (string, string, error) {
 	return user.Password, user.ID.Hex(), nil
 }
 
-func GetUserByID(ctx *gin.Context, id string) (*models.User, error) {
+func (r *MongoUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid user ID format")
+		return nil, ErrInvalidUserID
 	}
 
-	db := GetMongoDB()
-	collection := db.Collection("users")
+	collection := r.db.Collection("users")
 
-	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	dbCtx, cancel := r.timeouts.read(ctx)
 	defer cancel()
 
 	var user models.User
 	filter := bson.M{"_id": objectID}
 	err = collection.FindOne(dbCtx, filter).Decode(&user)
 	if err != nil {
-		if err.Error() == "mongo: no documents in result" {
-			return nil, errors.New("user not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -86,419 +112,92 @@ func GetUserByID(ctx *gin.Context, id string) (*models.User, error) {
 	return &user, nil
 }
 
-func AddToFavorites(ctx *gin.Context, userID string, propertyID string) error {
-	// Convert string IDs to ObjectIDs
-	userOID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return errors.New("invalid user ID format")
-	}
-
-	propertyOID, err := primitive.ObjectIDFromHex(propertyID)
-	if err != nil {
-		return errors.New("invalid property ID format")
-	}
-
-	db := GetMongoDB()
-	collection := db.Collection("users")
-
-	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Check if property is already in favorites
-	var user models.User
-	err = collection.FindOne(dbCtx, bson.M{
-		"_id": userOID,
-		"favorites": bson.M{
-			"$in": []primitive.ObjectID{propertyOID},
-		},
-	}).Decode(&user)
-
-	if err == nil {
-		// Property already in favorites
-		return nil
-	} else if err.Error() != "mongo: no documents in result" {
-		return err
-	}
-
-	// Add property to favorites
-	_, err = collection.UpdateOne(
-		dbCtx,
-		bson.M{"_id": userOID},
-		bson.M{"$addToSet": bson.M{"favorites": propertyOID}},
-	)
-
-	return err
-}
-
-func RemoveFromFavorites(ctx *gin.Context, userID string, propertyID string) error {
-
-	userOID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return errors.New("invalid user ID format")
-	}
-
-	propertyOID, err := primitive.ObjectIDFromHex(propertyID)
-	if err != nil {
-		return errors.New("invalid property ID format")
-	}
-
-	db := GetMongoDB()
-	collection := db.Collection("users")
-
-	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Remove property from favorites
-	_, err = collection.UpdateOne(
-		dbCtx,
-		bson.M{"_id": userOID},
-		bson.M{"$pull": bson.M{"favorites": propertyOID}},
-	)
-
-	return err
-}
-
-// GetUserFavorites retrieves a list of favorite properties for a user
-func GetUserFavorites(ctx *gin.Context, userID string) ([]models.Property, error) {
-	// Try to get from cache first
-	cacheKey := UserFavoritesKeyPrefix + userID
-	var favoriteProperties []models.Property
-	found, err := GetFromCache(ctx, cacheKey, &favoriteProperties)
-	if err != nil {
-		log.Printf("Error retrieving user favorites from cache: %v", err)
-	}
-
-	if found {
-		return favoriteProperties, nil
-	}
-
-	// Not in cache, get from database
-	db := GetMongoDB()
-	usersCollection := db.Collection("users")
-	propertiesCollection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	// Convert userID to ObjectID
+// RecordPropertyView moves a property to the end of the user's recently viewed list
+func (r *MongoUserRepository) RecordPropertyView(ctx context.Context, userID string, propertyID string) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, errors.New("invalid user ID format")
-	}
-
-	// Find the user to get their favorites
-	var user models.User
-	err = usersCollection.FindOne(dbCtx, bson.M{"_id": userObjID}).Decode(&user)
-	if err != nil {
-		return nil, err
-	}
-
-	// If no favorites, return empty slice
-	if len(user.Favorites) == 0 {
-		return []models.Property{}, nil
-	}
-
-	// Query for properties that match the favorite IDs
-	cursor, err := propertiesCollection.Find(dbCtx, bson.M{"_id": bson.M{"$in": user.Favorites}})
-	if err != nil {
-		return nil, err
+		return ErrInvalidUserID
 	}
-	defer cursor.Close(dbCtx)
 
-	if err := cursor.All(dbCtx, &favoriteProperties); err != nil {
-		return nil, err
-	}
-
-	// Store in cache for future requests
-	err = SetInCache(ctx, cacheKey, favoriteProperties, MediumTerm)
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
 	if err != nil {
-		log.Printf("Error caching user favorites: %v", err)
+		return ErrInvalidPropertyID
 	}
 
-	return favoriteProperties, nil
-}
-
-// AddFavoriteProperty adds a property to a user's favorites
-func AddFavoriteProperty(ctx *gin.Context, userID string, propertyID string) error {
-	db := GetMongoDB()
-	collection := db.Collection("users")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	collection := r.db.Collection("users")
+	dbCtx, cancel := r.timeouts.write(ctx)
 	defer cancel()
 
-	// Convert IDs to ObjectIDs
-	userObjID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return errors.New("invalid user ID format")
-	}
-
-	propObjID, err := primitive.ObjectIDFromHex(propertyID)
-	if err != nil {
-		return errors.New("invalid property ID format")
-	}
-
-	// Check if property exists
-	propertyCollection := db.Collection("properties")
-	count, err := propertyCollection.CountDocuments(dbCtx, bson.M{"_id": propObjID})
+	// Drop any earlier view of the same property so it is not counted twice
+	_, err = collection.UpdateOne(dbCtx, bson.M{"_id": userObjID}, bson.M{
+		"$pull": bson.M{"recentlyViewed": bson.M{"propertyId": propObjID}},
+	})
 	if err != nil {
 		return err
 	}
-	if count == 0 {
-		return errors.New("property not found")
-	}
 
-	// Add to favorites only if not already in favorites
-	update := bson.M{
-		"$addToSet": bson.M{
-			"favorites": propObjID,
+	_, err = collection.UpdateOne(dbCtx, bson.M{"_id": userObjID}, bson.M{
+		"$push": bson.M{
+			"recentlyViewed": bson.M{
+				"$each":  []models.PropertyView{{PropertyID: propObjID, ViewedAt: time.Now()}},
+				"$slice": -MaxRecentlyViewed,
+			},
 		},
-	}
-
-	result, err := collection.UpdateOne(dbCtx, bson.M{"_id": userObjID}, update)
+	})
 	if err != nil {
 		return err
 	}
 
-	if result.ModifiedCount == 0 {
-		// Check if user exists
-		count, err := collection.CountDocuments(dbCtx, bson.M{"_id": userObjID})
-		if err != nil {
-			return err
-		}
-		if count == 0 {
-			return errors.New("user not found")
-		}
-		// If user exists but nothing was modified, property is already in favorites
-		return errors.New("property already in favorites")
-	}
-
-	// Clear the favorites cache for this user
-	ClearUserFavoritesCache(ctx, userID)
+	// Viewed listings are no longer recommended, so the cached ones are stale
+	r.cache.ClearPersonalisedRecommendationsCache(ctx, userID)
 
 	return nil
 }
 
-// RemoveFavoriteProperty removes a property from a user's favorites
-func RemoveFavoriteProperty(ctx *gin.Context, userID string, propertyID string) error {
-	db := GetMongoDB()
-	collection := db.Collection("users")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	// Convert IDs to ObjectIDs
+// GetNotificationPreferences returns a user's alert settings
+func (r *MongoUserRepository) GetNotificationPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return errors.New("invalid user ID format")
+		return models.NotificationPreferences{}, ErrInvalidUserID
 	}
 
-	propObjID, err := primitive.ObjectIDFromHex(propertyID)
-	if err != nil {
-		return errors.New("invalid property ID format")
-	}
-
-	// Remove from favorites
-	update := bson.M{
-		"$pull": bson.M{
-			"favorites": propObjID,
-		},
-	}
-
-	result, err := collection.UpdateOne(dbCtx, bson.M{"_id": userObjID}, update)
-	if err != nil {
-		return err
-	}
-
-	if result.ModifiedCount == 0 {
-		// Check if user exists
-		count, err := collection.CountDocuments(dbCtx, bson.M{"_id": userObjID})
-		if err != nil {
-			return err
-		}
-		if count == 0 {
-			return errors.New("user not found")
-		}
-		// If user exists but nothing was modified, property was not in favorites
-		return errors.New("property not in favorites")
-	}
-
-	// Clear the favorites cache for this user
-	ClearUserFavoritesCache(ctx, userID)
-
-	return nil
-}
-
-// Functions for recommendations
-
-// GetReceivedRecommendations retrieves recommendations received by a user
-func GetReceivedRecommendations(ctx *gin.Context, userID string) ([]map[string]interface{}, error) {
-	// Try to get from cache first
-	cacheKey := "user:recommendations:" + userID
-	var recommendations []map[string]interface{}
-	found, err := GetFromCache(ctx, cacheKey, &recommendations)
-	if err != nil {
-		log.Printf("Error retrieving recommendations from cache: %v", err)
-	}
-
-	if found {
-		return recommendations, nil
-	}
-
-	// Not in cache, get from database
-	db := GetMongoDB()
-	usersCollection := db.Collection("users")
-	propertiesCollection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	dbCtx, cancel := r.timeouts.read(ctx)
 	defer cancel()
 
-	// Convert userID to ObjectID
-	userObjID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return nil, errors.New("invalid user ID format")
-	}
-
-	// Find the user to get their recommendations
 	var user models.User
-	err = usersCollection.FindOne(dbCtx, bson.M{"_id": userObjID}).Decode(&user)
-	if err != nil {
-		return nil, err
-	}
-
-	// If no recommendations, return empty slice
-	if len(user.RecommendationsReceived) == 0 {
-		return []map[string]interface{}{}, nil
-	}
-
-	// Gather all property IDs and recommender IDs for lookup
-	var propertyIDs []primitive.ObjectID
-	var recommenderIDs []primitive.ObjectID
-	for _, rec := range user.RecommendationsReceived {
-		propertyIDs = append(propertyIDs, rec.PropertyID)
-		recommenderIDs = append(recommenderIDs, rec.RecommendedBy)
-	}
-
-	// Get properties
-	propertiesCursor, err := propertiesCollection.Find(dbCtx, bson.M{"_id": bson.M{"$in": propertyIDs}})
-	if err != nil {
-		return nil, err
-	}
-	defer propertiesCursor.Close(dbCtx)
-
-	var properties []models.Property
-	if err := propertiesCursor.All(dbCtx, &properties); err != nil {
-		return nil, err
-	}
-
-	// Get recommenders
-	recommendersCursor, err := usersCollection.Find(dbCtx, bson.M{"_id": bson.M{"$in": recommenderIDs}})
+	err = r.db.Collection("users").FindOne(dbCtx,
+		bson.M{"_id": userObjID},
+		options.FindOne().SetProjection(bson.M{"notificationPreferences": 1}),
+	).Decode(&user)
 	if err != nil {
-		return nil, err
-	}
-	defer recommendersCursor.Close(dbCtx)
-
-	var recommenders []models.User
-	if err := recommendersCursor.All(dbCtx, &recommenders); err != nil {
-		return nil, err
-	}
-
-	// Create property and recommender maps for easy lookup
-	propertyMap := make(map[string]models.Property)
-	for _, property := range properties {
-		propertyMap[property.ID.Hex()] = property
-	}
-
-	recommenderMap := make(map[string]models.User)
-	for _, recommender := range recommenders {
-		recommenderMap[recommender.ID.Hex()] = recommender
-	}
-
-	// Build detailed recommendations
-	var detailedRecommendations []map[string]interface{}
-	for _, rec := range user.RecommendationsReceived {
-		property, propertyExists := propertyMap[rec.PropertyID.Hex()]
-		recommender, recommenderExists := recommenderMap[rec.RecommendedBy.Hex()]
-
-		if propertyExists && recommenderExists {
-			detailedRecommendation := map[string]interface{}{
-				"property":      property,
-				"recommendedBy": map[string]interface{}{"id": recommender.ID, "name": recommender.Name, "email": recommender.Email},
-				"recommendedAt": rec.RecommendedAt,
-			}
-			detailedRecommendations = append(detailedRecommendations, detailedRecommendation)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.NotificationPreferences{}, ErrUserNotFound
 		}
+		return models.NotificationPreferences{}, err
 	}
 
-	// Store in cache for future requests
-	err = SetInCache(ctx, cacheKey, detailedRecommendations, MediumTerm)
-	if err != nil {
-		log.Printf("Error caching recommendations: %v", err)
-	}
-
-	return detailedRecommendations, nil
+	return user.Preferences(), nil
 }
 
-// RecommendProperty adds a property recommendation to a user
-func RecommendProperty(ctx *gin.Context, fromUserID, toUserID, propertyID string) error {
-	db := GetMongoDB()
-	usersCollection := db.Collection("users")
-	propertiesCollection := db.Collection("properties")
-	dbCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	// Convert IDs to ObjectIDs
-	fromUserObjID, err := primitive.ObjectIDFromHex(fromUserID)
-	if err != nil {
-		return errors.New("invalid recommender user ID format")
-	}
-
-	toUserObjID, err := primitive.ObjectIDFromHex(toUserID)
-	if err != nil {
-		return errors.New("invalid recipient user ID format")
-	}
-
-	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+// UpdateNotificationPreferences replaces a user's alert settings
+func (r *MongoUserRepository) UpdateNotificationPreferences(ctx context.Context, userID string, preferences models.NotificationPreferences) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return errors.New("invalid property ID format")
+		return ErrInvalidUserID
 	}
 
-	// Check if property exists
-	count, err := propertiesCollection.CountDocuments(dbCtx, bson.M{"_id": propObjID})
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("property not found")
-	}
-
-	// Check if recipient user exists
-	count, err = usersCollection.CountDocuments(dbCtx, bson.M{"_id": toUserObjID})
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return errors.New("recipient user not found")
-	}
-
-	// Create recommendation
-	recommendation := models.Recommendation{
-		PropertyID:    propObjID,
-		RecommendedBy: fromUserObjID,
-		RecommendedAt: time.Now(),
-	}
-
-	// Add recommendation to recipient's recommendations
-	update := bson.M{
-		"$push": bson.M{
-			"recommendationsReceived": recommendation,
-		},
-	}
+	dbCtx, cancel := r.timeouts.write(ctx)
+	defer cancel()
 
-	_, err = usersCollection.UpdateOne(dbCtx, bson.M{"_id": toUserObjID}, update)
+	result, err := r.db.Collection("users").UpdateOne(dbCtx,
+		bson.M{"_id": userObjID},
+		bson.M{"$set": bson.M{"notificationPreferences": preferences}},
+	)
 	if err != nil {
 		return err
 	}
-
-	// Clear the recommendations cache for this user since they've received a new recommendation
-	err = DeleteFromCache(ctx, "user:recommendations:"+toUserID)
-	if err != nil {
-		log.Printf("Error clearing recommendations cache: %v", err)
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
 	}
 
 	return nil
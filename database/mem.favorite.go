@@ -0,0 +1,683 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryFavoriteRepository is the FavoriteRepository backed by a MemoryStore
+type MemoryFavoriteRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryFavoriteRepository(store *MemoryStore) *MemoryFavoriteRepository {
+	return &MemoryFavoriteRepository{store: store}
+}
+
+func (r *MemoryFavoriteRepository) ListFavorites(ctx context.Context, userID string) ([]models.Property, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.userIndex(userObjID) < 0 {
+		return nil, ErrUserNotFound
+	}
+
+	defaultCollection := r.ensureDefaultCollection(userObjID)
+	items := collectionItemDetails(defaultCollection.Items, r.store.properties)
+
+	favoriteProperties := make([]models.Property, 0, len(items))
+	for _, item := range items {
+		favoriteProperties = append(favoriteProperties, item.Property)
+	}
+
+	return favoriteProperties, nil
+}
+
+func (r *MemoryFavoriteRepository) AddFavorite(ctx context.Context, userID string, propertyID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.store.propertyVisibleTo(propObjID, userID) {
+		return ErrPropertyNotFound
+	}
+	if r.store.userIndex(userObjID) < 0 {
+		return ErrUserNotFound
+	}
+
+	defaultCollection := r.ensureDefaultCollection(userObjID)
+	if !pushItem(defaultCollection, propObjID, "", userObjID) {
+		return ErrAlreadyInFavorites
+	}
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) RemoveFavorite(ctx context.Context, userID string, propertyID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findCollection(bson.M{"userId": userObjID, "isDefault": true, "items.propertyId": propObjID})
+	if i < 0 {
+		if r.store.userIndex(userObjID) < 0 {
+			return ErrUserNotFound
+		}
+		return ErrNotInFavorites
+	}
+	pullItem(&r.store.collections[i], propObjID)
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) FindUsersWhoSaved(ctx context.Context, propertyID primitive.ObjectID) ([]models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	saverIDs := make(map[primitive.ObjectID]bool)
+	for i := range r.store.collections {
+		if itemIndex(r.store.collections[i].Items, propertyID) >= 0 {
+			saverIDs[r.store.collections[i].UserID] = true
+		}
+	}
+
+	users := []models.User{}
+	for _, user := range r.store.users {
+		if saverIDs[user.ID] {
+			users = append(users, models.User{
+				ID:                      user.ID,
+				Name:                    user.Name,
+				Email:                   user.Email,
+				NotificationPreferences: cloneUser(user).NotificationPreferences,
+			})
+		}
+	}
+
+	return users, nil
+}
+
+func (r *MemoryFavoriteRepository) ListCollections(ctx context.Context, userID string) ([]models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.ensureDefaultCollection(userObjID)
+
+	collections := []models.FavoriteCollection{}
+	for _, favoriteCollection := range r.store.collections {
+		if favoriteCollection.UserID == userObjID {
+			collections = append(collections, cloneCollection(favoriteCollection))
+		}
+	}
+	sort.SliceStable(collections, func(i, j int) bool {
+		if collections[i].IsDefault != collections[j].IsDefault {
+			return collections[i].IsDefault
+		}
+		return collections[i].CreatedAt.Before(collections[j].CreatedAt)
+	})
+
+	return collections, nil
+}
+
+func (r *MemoryFavoriteRepository) CreateCollection(ctx context.Context, userID string, name string) (*models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	name, err = validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.nameTaken(userObjID, name, primitive.NilObjectID) {
+		return nil, ErrDuplicateCollection
+	}
+
+	now := time.Now()
+	favoriteCollection := models.FavoriteCollection{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjID,
+		Name:      name,
+		Items:     []models.FavoriteItem{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.store.collections = append(r.store.collections, favoriteCollection)
+
+	return &favoriteCollection, nil
+}
+
+func (r *MemoryFavoriteRepository) GetCollection(ctx context.Context, ownerID string, actorID string, collectionID string) (*models.FavoriteCollection, error) {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return nil, ErrCollectionNotFound
+	}
+
+	favoriteCollection := cloneCollection(r.store.collections[i])
+	return &favoriteCollection, nil
+}
+
+func (r *MemoryFavoriteRepository) GetCollectionItems(ctx context.Context, favoriteCollection *models.FavoriteCollection) ([]models.FavoriteItemDetail, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return collectionItemDetails(favoriteCollection.Items, r.store.properties), nil
+}
+
+func (r *MemoryFavoriteRepository) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (*models.FavoriteCollection, error) {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err = validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.nameTaken(filter["userId"].(primitive.ObjectID), name, filter["_id"].(primitive.ObjectID)) {
+		return nil, ErrDuplicateCollection
+	}
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return nil, ErrCollectionNotFound
+	}
+
+	r.store.collections[i].Name = name
+	r.store.collections[i].UpdatedAt = time.Now()
+
+	updated := cloneCollection(r.store.collections[i])
+	return &updated, nil
+}
+
+func (r *MemoryFavoriteRepository) DeleteCollection(ctx context.Context, userID string, collectionID string) error {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollectionNotFound
+	}
+	if r.store.collections[i].IsDefault {
+		return ErrDefaultCollection
+	}
+	r.store.collections = append(r.store.collections[:i], r.store.collections[i+1:]...)
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) AddCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, note string) error {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	actorObjID, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.store.propertyVisibleTo(propObjID, actorID) {
+		return ErrPropertyNotFound
+	}
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollectionNotFound
+	}
+	if !pushItem(&r.store.collections[i], propObjID, note, actorObjID) {
+		return ErrAlreadyInCollection
+	}
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) UpdateCollectionItemNote(ctx context.Context, userID string, collectionID string, propertyID string, note string) error {
+	filter, err := collectionOwnerFilter(userID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	filter["items.propertyId"] = propObjID
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrNotInCollection
+	}
+
+	favoriteCollection := &r.store.collections[i]
+	favoriteCollection.Items[itemIndex(favoriteCollection.Items, propObjID)].Note = note
+	favoriteCollection.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) RemoveCollectionItem(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string) error {
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollectionNotFound
+	}
+	if !pullItem(&r.store.collections[i], propObjID) {
+		return ErrNotInCollection
+	}
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) CreateShareLink(ctx context.Context, ownerID string, collectionID string) (string, error) {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return "", err
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return "", ErrCollectionNotFound
+	}
+	r.store.collections[i].ShareToken = token
+	r.store.collections[i].UpdatedAt = time.Now()
+
+	return token, nil
+}
+
+func (r *MemoryFavoriteRepository) RevokeShareLink(ctx context.Context, ownerID string, collectionID string) error {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollectionNotFound
+	}
+	r.store.collections[i].ShareToken = ""
+	r.store.collections[i].UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) GetSharedCollection(ctx context.Context, token string) (*models.FavoriteCollection, error) {
+	if token == "" {
+		return nil, ErrCollectionNotFound
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, favoriteCollection := range r.store.collections {
+		if favoriteCollection.ShareToken == token {
+			shared := cloneCollection(favoriteCollection)
+			return &shared, nil
+		}
+	}
+
+	return nil, ErrCollectionNotFound
+}
+
+func (r *MemoryFavoriteRepository) AddCollaborator(ctx context.Context, ownerID string, collectionID string, collaboratorID string) error {
+	filter, err := collectionOwnerFilter(ownerID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	if collaboratorID == ownerID {
+		return ErrOwnerAsCollaborator
+	}
+
+	collaboratorObjID, err := primitive.ObjectIDFromHex(collaboratorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.userIndex(collaboratorObjID) < 0 {
+		return ErrUserNotFound
+	}
+
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollectionNotFound
+	}
+
+	favoriteCollection := &r.store.collections[i]
+	if !containsObjectID(favoriteCollection.Collaborators, collaboratorObjID) {
+		favoriteCollection.Collaborators = append(favoriteCollection.Collaborators, collaboratorObjID)
+	}
+	favoriteCollection.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) RemoveCollaborator(ctx context.Context, ownerID string, actorID string, collectionID string, collaboratorID string) error {
+	if actorID != ownerID && actorID != collaboratorID {
+		return ErrCollectionNotFound
+	}
+
+	filter, err := collectionMemberFilter(ownerID, actorID, collectionID)
+	if err != nil {
+		return err
+	}
+
+	collaboratorObjID, err := primitive.ObjectIDFromHex(collaboratorID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	filter["collaborators"] = collaboratorObjID
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return ErrCollaboratorNotFound
+	}
+
+	favoriteCollection := &r.store.collections[i]
+	collaborators := favoriteCollection.Collaborators[:0]
+	for _, id := range favoriteCollection.Collaborators {
+		if id != collaboratorObjID {
+			collaborators = append(collaborators, id)
+		}
+	}
+	favoriteCollection.Collaborators = collaborators
+	favoriteCollection.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) ListSharedWithUser(ctx context.Context, userID string) ([]models.FavoriteCollection, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	collections := []models.FavoriteCollection{}
+	for _, favoriteCollection := range r.store.collections {
+		if containsObjectID(favoriteCollection.Collaborators, userObjID) {
+			shared := cloneCollection(favoriteCollection)
+			shared.ShareToken = ""
+			for i := range shared.Items {
+				shared.Items[i].Note = ""
+			}
+			collections = append(collections, shared)
+		}
+	}
+	sort.SliceStable(collections, func(i, j int) bool {
+		return collections[i].UpdatedAt.After(collections[j].UpdatedAt)
+	})
+
+	return collections, nil
+}
+
+func (r *MemoryFavoriteRepository) AddItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, text string) (*models.ItemComment, error) {
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrCommentRequired
+	}
+	if len(text) > maxCommentLength {
+		return nil, ErrCommentTooLong
+	}
+
+	actorObjID, _ := primitive.ObjectIDFromHex(actorID)
+	comment := models.ItemComment{
+		ID:        primitive.NewObjectID(),
+		UserID:    actorObjID,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, favoriteCollection := r.findItem(filter)
+	if item == nil {
+		return nil, ErrNotInCollection
+	}
+	item.Comments = append(item.Comments, comment)
+	favoriteCollection.UpdatedAt = comment.CreatedAt
+
+	return &comment, nil
+}
+
+func (r *MemoryFavoriteRepository) DeleteItemComment(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, commentID string) error {
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return err
+	}
+
+	commentObjID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		return ErrInvalidCommentID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, favoriteCollection := r.findItem(filter)
+	if item == nil {
+		return ErrCommentNotFound
+	}
+
+	for i, comment := range item.Comments {
+		// Members may delete their own comments and the owner may delete any comment
+		if comment.ID == commentObjID && (actorID == ownerID || comment.UserID.Hex() == actorID) {
+			item.Comments = append(item.Comments[:i], item.Comments[i+1:]...)
+			favoriteCollection.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+
+	return ErrCommentNotFound
+}
+
+func (r *MemoryFavoriteRepository) SetItemReaction(ctx context.Context, ownerID string, actorID string, collectionID string, propertyID string, value string) error {
+	if value != "" && value != models.ReactionUp && value != models.ReactionDown {
+		return ErrInvalidReaction
+	}
+
+	filter, err := collectionItemFilter(ownerID, actorID, collectionID, propertyID)
+	if err != nil {
+		return err
+	}
+
+	actorObjID, _ := primitive.ObjectIDFromHex(actorID)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, favoriteCollection := r.findItem(filter)
+	if item == nil {
+		return ErrNotInCollection
+	}
+
+	// Drop the member's previous reaction before recording the new one
+	reactions := item.Reactions[:0]
+	for _, reaction := range item.Reactions {
+		if reaction.UserID != actorObjID {
+			reactions = append(reactions, reaction)
+		}
+	}
+	item.Reactions = reactions
+
+	if value == "" {
+		return nil
+	}
+
+	reaction := models.ItemReaction{UserID: actorObjID, Value: value, ReactedAt: time.Now()}
+	item.Reactions = append(item.Reactions, reaction)
+	favoriteCollection.UpdatedAt = reaction.ReactedAt
+
+	return nil
+}
+
+// ensureDefaultCollection returns the user's default collection, creating it if needed.
+// The caller must hold the write lock.
+func (r *MemoryFavoriteRepository) ensureDefaultCollection(userObjID primitive.ObjectID) *models.FavoriteCollection {
+	if i := r.store.findCollection(bson.M{"userId": userObjID, "isDefault": true}); i >= 0 {
+		return &r.store.collections[i]
+	}
+
+	now := time.Now()
+	r.store.collections = append(r.store.collections, models.FavoriteCollection{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjID,
+		Name:      models.DefaultCollectionName,
+		IsDefault: true,
+		Items:     []models.FavoriteItem{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	return &r.store.collections[len(r.store.collections)-1]
+}
+
+// nameTaken reports whether another collection of the user already has the name
+func (r *MemoryFavoriteRepository) nameTaken(userObjID primitive.ObjectID, name string, exceptID primitive.ObjectID) bool {
+	for _, favoriteCollection := range r.store.collections {
+		if favoriteCollection.UserID == userObjID && favoriteCollection.Name == name && favoriteCollection.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// findItem returns the saved item matched by a collectionItemFilter and its collection
+func (r *MemoryFavoriteRepository) findItem(filter bson.M) (*models.FavoriteItem, *models.FavoriteCollection) {
+	i := r.store.findCollection(filter)
+	if i < 0 {
+		return nil, nil
+	}
+
+	favoriteCollection := &r.store.collections[i]
+	j := itemIndex(favoriteCollection.Items, filter["items.propertyId"].(primitive.ObjectID))
+	return &favoriteCollection.Items[j], favoriteCollection
+}
+
+// pushItem appends the property unless the collection already holds it
+func pushItem(favoriteCollection *models.FavoriteCollection, propObjID primitive.ObjectID, note string, addedBy primitive.ObjectID) bool {
+	if itemIndex(favoriteCollection.Items, propObjID) >= 0 {
+		return false
+	}
+
+	now := time.Now()
+	favoriteCollection.Items = append(favoriteCollection.Items, models.FavoriteItem{PropertyID: propObjID, Note: note, SavedAt: now, AddedBy: addedBy})
+	favoriteCollection.UpdatedAt = now
+	return true
+}
+
+func pullItem(favoriteCollection *models.FavoriteCollection, propObjID primitive.ObjectID) bool {
+	i := itemIndex(favoriteCollection.Items, propObjID)
+	if i < 0 {
+		return false
+	}
+
+	favoriteCollection.Items = append(favoriteCollection.Items[:i], favoriteCollection.Items[i+1:]...)
+	favoriteCollection.UpdatedAt = time.Now()
+	return true
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationRepository is the NotificationRepository backed by a MemoryStore
+type MemoryNotificationRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryNotificationRepository(store *MemoryStore) *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{store: store}
+}
+
+func (r *MemoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.notifications = append(r.store.notifications, *notification)
+	return nil
+}
+
+func (r *MemoryNotificationRepository) List(ctx context.Context, userID string, unreadOnly bool, limit int64) ([]models.Notification, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if limit <= 0 || limit > maxInboxPageSize {
+		limit = maxInboxPageSize
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Notifications are appended in creation order, so walking backwards is newest first
+	notifications := []models.Notification{}
+	for i := len(r.store.notifications) - 1; i >= 0 && int64(len(notifications)) < limit; i-- {
+		notification := r.store.notifications[i]
+		if notification.UserID != userObjID || (unreadOnly && notification.ReadAt != nil) {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (r *MemoryNotificationRepository) MarkRead(ctx context.Context, userID string, notificationID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	notificationObjID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return ErrInvalidNotificationID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.notifications {
+		notification := &r.store.notifications[i]
+		if notification.ID == notificationObjID && notification.UserID == userObjID {
+			readAt := time.Now()
+			notification.ReadAt = &readAt
+			return nil
+		}
+	}
+
+	return ErrNotificationNotFound
+}
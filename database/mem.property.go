@@ -0,0 +1,334 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"bytes"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPropertyRepository is the PropertyRepository backed by a MemoryStore
+type MemoryPropertyRepository struct {
+	store *MemoryStore
+	hooks []PropertyUpdateHook
+}
+
+func NewMemoryPropertyRepository(store *MemoryStore) *MemoryPropertyRepository {
+	return &MemoryPropertyRepository{store: store}
+}
+
+func (r *MemoryPropertyRepository) OnUpdated(hook PropertyUpdateHook) {
+	r.hooks = append(r.hooks, hook)
+}
+
+func (r *MemoryPropertyRepository) GetByID(ctx context.Context, propertyID string) (*models.Property, error) {
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return nil, ErrInvalidPropertyID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.propertyIndex(propObjID)
+	if i < 0 {
+		return nil, ErrPropertyNotFound
+	}
+
+	property := r.store.properties[i]
+	return &property, nil
+}
+
+// GetLatest is GetByID, as the memory store has no cache to bypass
+func (r *MemoryPropertyRepository) GetLatest(ctx context.Context, propertyID string) (*models.Property, error) {
+	return r.GetByID(ctx, propertyID)
+}
+
+func (r *MemoryPropertyRepository) List(ctx context.Context, filter models.PropertyFilter) ([]models.Property, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	properties := []models.Property{}
+	for _, property := range r.store.properties {
+		if propertyMatches(property, filter) {
+			properties = append(properties, property)
+		}
+	}
+
+	return properties, nil
+}
+
+func (r *MemoryPropertyRepository) ListByOwner(ctx context.Context, ownerID string) ([]models.Property, error) {
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	properties := []models.Property{}
+	for _, property := range r.store.properties {
+		if property.CreatedBy == userObjID {
+			properties = append(properties, property)
+		}
+	}
+
+	return properties, nil
+}
+
+func (r *MemoryPropertyRepository) Create(ctx context.Context, property *models.Property) (*models.Property, error) {
+	initProperty(property, time.Now())
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.propertyIndex(property.ID) >= 0 {
+		return nil, ErrDuplicatePropertyID
+	}
+	r.store.properties = append(r.store.properties, *property)
+
+	return property, nil
+}
+
+func (r *MemoryPropertyRepository) Update(ctx context.Context, ownerID string, propertyID string, version int64, propertyUpdate PropertyUpdate) (*models.Property, error) {
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return nil, ErrInvalidPropertyID
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	i := r.store.propertyIndex(propObjID)
+	if i < 0 {
+		r.store.mu.Unlock()
+		return nil, ErrPropertyNotFound
+	}
+	if r.store.properties[i].CreatedBy != userObjID {
+		r.store.mu.Unlock()
+		return nil, ErrPropertyNotOwned
+	}
+	if version != AnyVersion && r.store.properties[i].Version != version {
+		r.store.mu.Unlock()
+		return nil, ErrVersionMismatch
+	}
+
+	previousProperty := r.store.properties[i]
+	if err := checkExpected(previousProperty, propertyUpdate.Expect); err != nil {
+		r.store.mu.Unlock()
+		return nil, err
+	}
+	updatedProperty, err := applyPropertyUpdate(previousProperty, propertyUpdate)
+	if err != nil {
+		r.store.mu.Unlock()
+		return nil, err
+	}
+	updatedProperty.Version = previousProperty.Version + 1
+	r.store.properties[i] = updatedProperty
+	r.store.mu.Unlock()
+
+	for _, hook := range r.hooks {
+		hook(previousProperty, updatedProperty)
+	}
+
+	return &updatedProperty, nil
+}
+
+func (r *MemoryPropertyRepository) Delete(ctx context.Context, ownerID string, propertyID string, version int64) error {
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.propertyIndex(propObjID)
+	if i < 0 {
+		return ErrPropertyNotFound
+	}
+	if r.store.properties[i].CreatedBy != userObjID {
+		return ErrPropertyNotOwned
+	}
+	if version != AnyVersion && r.store.properties[i].Version != version {
+		return ErrVersionMismatch
+	}
+	r.store.properties = append(r.store.properties[:i], r.store.properties[i+1:]...)
+
+	return nil
+}
+
+func (r *MemoryPropertyRepository) ArchiveExpired(ctx context.Context, renewedBefore time.Time) (int64, error) {
+	now := time.Now()
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var archived int64
+	for i, property := range r.store.properties {
+		if property.Status != models.PropertyActive || property.RenewedAt == nil || !property.RenewedAt.Before(renewedBefore) {
+			continue
+		}
+		property.Status = models.PropertyArchived
+		property.ArchivedAt = &now
+		property.Version++
+		r.store.properties[i] = property
+		archived++
+	}
+
+	return archived, nil
+}
+
+// checkExpected is the in-memory equivalent of the Expect values in the Mongo
+// update filter
+func checkExpected(property models.Property, expect map[string]interface{}) error {
+	if len(expect) == 0 {
+		return nil
+	}
+
+	doc, err := propertyDocument(property)
+	if err != nil {
+		return err
+	}
+	for key, value := range expect {
+		equal, err := bsonValuesEqual(doc[key], value)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return ErrPropertyChanged
+		}
+	}
+	return nil
+}
+
+// applyPropertyUpdate is the in-memory equivalent of propertyUpdateDocument. The
+// property is round-tripped through BSON so fields are addressed by their stored
+// names and decoded with the same rules; Expect is checked by checkExpected.
+func applyPropertyUpdate(property models.Property, propertyUpdate PropertyUpdate) (models.Property, error) {
+	doc, err := propertyDocument(property)
+	if err != nil {
+		return property, err
+	}
+
+	for key, value := range propertyUpdate.Set {
+		doc[key] = value
+	}
+	for _, key := range propertyUpdate.Unset {
+		delete(doc, key)
+	}
+	for key, values := range propertyUpdate.AddToSet {
+		current, _ := doc[key].(bson.A)
+		for _, value := range values {
+			if !containsValue(current, value) {
+				current = append(current, value)
+			}
+		}
+		doc[key] = current
+	}
+	for key, values := range propertyUpdate.Pull {
+		current, _ := doc[key].(bson.A)
+		kept := bson.A{}
+		for _, value := range current {
+			if !containsString(values, value) {
+				kept = append(kept, value)
+			}
+		}
+		doc[key] = kept
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return property, err
+	}
+
+	var updated models.Property
+	if err := bson.Unmarshal(raw, &updated); err != nil {
+		return property, err
+	}
+
+	return updated, nil
+}
+
+// propertyDocument returns a property's fields as they are stored
+func propertyDocument(property models.Property) (bson.M, error) {
+	raw, err := bson.Marshal(property)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func containsValue(values bson.A, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bsonValuesEqual compares values the way a MongoDB equality filter would, by
+// their BSON encoding
+func bsonValuesEqual(a interface{}, b interface{}) (bool, error) {
+	rawA, err := bson.Marshal(bson.D{{Key: "v", Value: a}})
+	if err != nil {
+		return false, err
+	}
+	rawB, err := bson.Marshal(bson.D{{Key: "v", Value: b}})
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(rawA, rawB), nil
+}
+
+// propertyMatches is the in-memory equivalent of propertyFilterQuery
+func propertyMatches(property models.Property, filter models.PropertyFilter) bool {
+	switch {
+	case filter.Type != "" && property.Type != filter.Type:
+		return false
+	case filter.City != "" && property.City != filter.City:
+		return false
+	case filter.State != "" && property.State != filter.State:
+		return false
+	case filter.MinPrice != nil && property.Price < *filter.MinPrice:
+		return false
+	case filter.MaxPrice != nil && property.Price > *filter.MaxPrice:
+		return false
+	case filter.Bedrooms != nil && property.Bedrooms != *filter.Bedrooms:
+		return false
+	case filter.Bathrooms != nil && property.Bathrooms != *filter.Bathrooms:
+		return false
+	case filter.Furnished != "" && property.Furnished != filter.Furnished:
+		return false
+	case filter.Status != "" && property.Status != filter.Status:
+		return false
+	}
+	return true
+}
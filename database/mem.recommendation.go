@@ -0,0 +1,235 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRecommendationRepository is the RecommendationRepository backed by a MemoryStore.
+// Personalised recommendations are computed on every call since there is nothing to precompute into.
+type MemoryRecommendationRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryRecommendationRepository(store *MemoryStore) *MemoryRecommendationRepository {
+	return &MemoryRecommendationRepository{store: store}
+}
+
+func (r *MemoryRecommendationRepository) ListReceived(ctx context.Context, userID string) ([]map[string]interface{}, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.userIndex(userObjID)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+
+	user := r.store.users[i]
+	if len(user.RecommendationsReceived) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	var detailedRecommendations []map[string]interface{}
+	for _, rec := range user.RecommendationsReceived {
+		propertyIndex := r.store.propertyIndex(rec.PropertyID)
+		recommenderIndex := r.store.userIndex(rec.RecommendedBy)
+
+		if propertyIndex >= 0 && recommenderIndex >= 0 {
+			recommender := r.store.users[recommenderIndex]
+			detailedRecommendations = append(detailedRecommendations, map[string]interface{}{
+				"property":      r.store.properties[propertyIndex],
+				"recommendedBy": map[string]interface{}{"id": recommender.ID, "name": recommender.Name, "email": recommender.Email},
+				"recommendedAt": rec.RecommendedAt,
+			})
+		}
+	}
+
+	return detailedRecommendations, nil
+}
+
+func (r *MemoryRecommendationRepository) Recommend(ctx context.Context, fromUserID, toUserID, propertyID string) error {
+	fromUserObjID, err := primitive.ObjectIDFromHex(fromUserID)
+	if err != nil {
+		return ErrInvalidRecommenderID
+	}
+
+	toUserObjID, err := primitive.ObjectIDFromHex(toUserID)
+	if err != nil {
+		return ErrInvalidRecipientID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.propertyIndex(propObjID) < 0 {
+		return ErrPropertyNotFound
+	}
+
+	i := r.store.userIndex(toUserObjID)
+	if i < 0 {
+		return ErrRecipientNotFound
+	}
+
+	r.store.users[i].RecommendationsReceived = append(r.store.users[i].RecommendationsReceived, models.Recommendation{
+		PropertyID:    propObjID,
+		RecommendedBy: fromUserObjID,
+		RecommendedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (r *MemoryRecommendationRepository) GetPersonalised(ctx context.Context, userID string) ([]models.PersonalisedRecommendation, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.userIndex(userObjID)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+
+	return r.computeForUser(r.store.users[i]), nil
+}
+
+func (r *MemoryRecommendationRepository) PrecomputePersonalised(ctx context.Context) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Every user counts as refreshed, as in the Mongo repository, which also
+	// stores empty results
+	return len(r.store.users), nil
+}
+
+func (r *MemoryRecommendationRepository) GetAlsoSaved(ctx context.Context, propertyID string) ([]models.AlsoSavedProperty, error) {
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return nil, ErrInvalidPropertyID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Keep the ranking order; neighbours deleted or taken off the market since the
+	// last run are skipped
+	alsoSaved := []models.AlsoSavedProperty{}
+	for _, neighbour := range r.store.neighbours[propObjID] {
+		if i := r.store.propertyIndex(neighbour.PropertyID); i >= 0 && r.store.properties[i].Status == models.PropertyActive {
+			alsoSaved = append(alsoSaved, models.AlsoSavedProperty{Property: r.store.properties[i], Support: neighbour.Support})
+		}
+	}
+
+	return alsoSaved, nil
+}
+
+func (r *MemoryRecommendationRepository) ComputeCooccurrence(ctx context.Context, minSupport int, topN int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// One list per user, merging all of their collections
+	var userOrder []primitive.ObjectID
+	byUser := make(map[primitive.ObjectID][]primitive.ObjectID)
+	for _, favoriteCollection := range r.store.collections {
+		if _, ok := byUser[favoriteCollection.UserID]; !ok {
+			userOrder = append(userOrder, favoriteCollection.UserID)
+		}
+		for _, item := range favoriteCollection.Items {
+			byUser[favoriteCollection.UserID] = append(byUser[favoriteCollection.UserID], item.PropertyID)
+		}
+	}
+
+	var favoriteLists [][]primitive.ObjectID
+	for _, userObjID := range userOrder {
+		if favorites := dedupeObjectIDs(byUser[userObjID]); len(favorites) > 1 {
+			favoriteLists = append(favoriteLists, favorites)
+		}
+	}
+
+	r.store.neighbours = BuildCooccurrence(favoriteLists, minSupport, topN)
+
+	return len(r.store.neighbours), nil
+}
+
+// computeForUser mirrors the Mongo recommendation query: unseen active listings in the
+// user's top cities or types within the price band, best rated first. The caller must hold the lock.
+func (r *MemoryRecommendationRepository) computeForUser(user models.User) []models.PersonalisedRecommendation {
+	var favorites []primitive.ObjectID
+	for _, favoriteCollection := range r.store.collections {
+		if favoriteCollection.UserID != user.ID {
+			continue
+		}
+		for _, item := range favoriteCollection.Items {
+			favorites = append(favorites, item.PropertyID)
+		}
+	}
+
+	seedIDs, weights := preferenceSeeds(dedupeObjectIDs(favorites), user.RecentlyViewed)
+	if len(seedIDs) == 0 {
+		return []models.PersonalisedRecommendation{}
+	}
+
+	var seeds []models.Property
+	for _, id := range seedIDs {
+		if i := r.store.propertyIndex(id); i >= 0 && r.store.properties[i].Status == models.PropertyActive {
+			seeds = append(seeds, r.store.properties[i])
+		}
+	}
+
+	profile := BuildPreferenceProfile(seeds, weights)
+	if len(profile.Seeds) == 0 {
+		return []models.PersonalisedRecommendation{}
+	}
+
+	cities := toSet(topKeys(profile.Cities, candidateCities))
+	types := toSet(topKeys(profile.Types, candidateTypes))
+	seedSet := make(map[primitive.ObjectID]bool, len(seedIDs))
+	for _, id := range seedIDs {
+		seedSet[id] = true
+	}
+
+	var candidates []models.Property
+	for _, property := range r.store.properties {
+		if property.Status != models.PropertyActive || seedSet[property.ID] || (!cities[property.City] && !types[property.Type]) {
+			continue
+		}
+		if property.Price < profile.MinPrice || property.Price > profile.MaxPrice {
+			continue
+		}
+		candidates = append(candidates, property)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Rating > candidates[j].Rating
+	})
+	if len(candidates) > candidatePoolSize {
+		candidates = candidates[:candidatePoolSize]
+	}
+
+	return RankCandidates(profile, candidates, MaxPersonalisedRecommendations)
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
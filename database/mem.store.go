@@ -0,0 +1,135 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore holds the documents of the in-memory repositories, one slice per
+// Mongo collection in insertion order. The repositories built on a store return
+// the same results and error messages as their Mongo counterparts, so handlers
+// can be exercised without a database.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	properties    []models.Property
+	users         []models.User
+	collections   []models.FavoriteCollection
+	neighbours    map[primitive.ObjectID][]models.Neighbour
+	notifications []models.Notification
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{neighbours: make(map[primitive.ObjectID][]models.Neighbour)}
+}
+
+func (s *MemoryStore) propertyIndex(id primitive.ObjectID) int {
+	for i := range s.properties {
+		if s.properties[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// propertyVisibleTo is the in-memory equivalent of ensurePropertyVisible
+func (s *MemoryStore) propertyVisibleTo(id primitive.ObjectID, userID string) bool {
+	i := s.propertyIndex(id)
+	return i >= 0 && s.properties[i].VisibleTo(userID)
+}
+
+func (s *MemoryStore) userIndex(id primitive.ObjectID) int {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findCollection returns the index of the first collection matching a filter built by
+// collectionOwnerFilter, collectionMemberFilter or collectionItemFilter
+func (s *MemoryStore) findCollection(filter bson.M) int {
+	for i := range s.collections {
+		if collectionMatches(&s.collections[i], filter) {
+			return i
+		}
+	}
+	return -1
+}
+
+func collectionMatches(favoriteCollection *models.FavoriteCollection, filter bson.M) bool {
+	for key, value := range filter {
+		switch key {
+		case "_id":
+			if favoriteCollection.ID != value.(primitive.ObjectID) {
+				return false
+			}
+		case "userId":
+			if favoriteCollection.UserID != value.(primitive.ObjectID) {
+				return false
+			}
+		case "isDefault":
+			if favoriteCollection.IsDefault != value.(bool) {
+				return false
+			}
+		case "collaborators":
+			if !containsObjectID(favoriteCollection.Collaborators, value.(primitive.ObjectID)) {
+				return false
+			}
+		case "items.propertyId":
+			if itemIndex(favoriteCollection.Items, value.(primitive.ObjectID)) < 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func itemIndex(items []models.FavoriteItem, propertyID primitive.ObjectID) int {
+	for i := range items {
+		if items[i].PropertyID == propertyID {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneCollection copies a collection deeply enough that callers can't mutate the store
+func cloneCollection(favoriteCollection models.FavoriteCollection) models.FavoriteCollection {
+	clone := favoriteCollection
+	clone.Collaborators = append([]primitive.ObjectID(nil), favoriteCollection.Collaborators...)
+	clone.Items = make([]models.FavoriteItem, len(favoriteCollection.Items))
+	for i, item := range favoriteCollection.Items {
+		item.Comments = append([]models.ItemComment(nil), item.Comments...)
+		item.Reactions = append([]models.ItemReaction(nil), item.Reactions...)
+		clone.Items[i] = item
+	}
+	return clone
+}
+
+func cloneUser(user models.User) models.User {
+	clone := user
+	clone.RecommendationsReceived = append([]models.Recommendation(nil), user.RecommendationsReceived...)
+	clone.RecentlyViewed = append([]models.PropertyView(nil), user.RecentlyViewed...)
+	if user.NotificationPreferences != nil {
+		preferences := *user.NotificationPreferences
+		preferences.Channels = append([]string(nil), preferences.Channels...)
+		clone.NotificationPreferences = &preferences
+	}
+	return clone
+}
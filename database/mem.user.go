@@ -0,0 +1,131 @@
+package database
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository is the UserRepository backed by a MemoryStore
+type MemoryUserRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryUserRepository(store *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+func (r *MemoryUserRepository) FirstOrCreate(ctx context.Context, user *models.User) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existingUser := range r.store.users {
+		if existingUser.Email == user.Email {
+			return existingUser.ID.Hex(), nil
+		}
+	}
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.CreatedAt = time.Now()
+	r.store.users = append(r.store.users, cloneUser(*user))
+
+	return user.ID.Hex(), nil
+}
+
+func (r *MemoryUserRepository) GetCredentials(ctx context.Context, email string) (string, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return user.Password, user.ID.Hex(), nil
+		}
+	}
+
+	return "", "", ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	i := r.store.userIndex(objectID)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+
+	user := cloneUser(r.store.users[i])
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) RecordPropertyView(ctx context.Context, userID string, propertyID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	propObjID, err := primitive.ObjectIDFromHex(propertyID)
+	if err != nil {
+		return ErrInvalidPropertyID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Like an update matching no user, views of unknown users are ignored
+	i := r.store.userIndex(userObjID)
+	if i < 0 {
+		return nil
+	}
+
+	user := &r.store.users[i]
+	views := make([]models.PropertyView, 0, len(user.RecentlyViewed)+1)
+	for _, view := range user.RecentlyViewed {
+		if view.PropertyID != propObjID {
+			views = append(views, view)
+		}
+	}
+	views = append(views, models.PropertyView{PropertyID: propObjID, ViewedAt: time.Now()})
+	if len(views) > MaxRecentlyViewed {
+		views = views[len(views)-MaxRecentlyViewed:]
+	}
+	user.RecentlyViewed = views
+
+	return nil
+}
+
+func (r *MemoryUserRepository) GetNotificationPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error) {
+	user, err := r.GetByID(ctx, userID)
+	if err != nil {
+		return models.NotificationPreferences{}, err
+	}
+
+	return user.Preferences(), nil
+}
+
+func (r *MemoryUserRepository) UpdateNotificationPreferences(ctx context.Context, userID string, preferences models.NotificationPreferences) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidUserID
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.userIndex(userObjID)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	r.store.users[i].NotificationPreferences = &preferences
+
+	return nil
+}
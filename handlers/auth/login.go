@@ -1,50 +1,55 @@
 package auth
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/metrics"
 	"Praiseson6065/Hypergro-assign/middleware"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ErrInvalidCredentials is returned for both unknown emails and wrong passwords,
+// so a failed login does not reveal which accounts exist
+var ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "Invalid email or password")
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
-func UserLogin() gin.HandlerFunc {
+func UserLogin(repo database.UserRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		var loginRequest LoginRequest
 		if err := ctx.ShouldBindBodyWithJSON(&loginRequest); err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(middleware.InvalidBody(err))
 			return
 		}
-		hashedPwd, userId, err := database.GetPasswordByMail(ctx, loginRequest.Email)
+		hashedPwd, userId, err := repo.GetCredentials(ctx, loginRequest.Email)
 
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			metrics.Logins.Inc("failure")
+			if errors.Is(err, database.ErrUserNotFound) {
+				err = ErrInvalidCredentials
+			}
+			ctx.Error(err)
 			return
 		}
 		if !comparePasswords(hashedPwd, loginRequest.Password) {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid password",
-			})
+			metrics.Logins.Inc("failure")
+			ctx.Error(ErrInvalidCredentials)
 			return
 		}
 		token, err := middleware.GenerateToken(userId)
 
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
+		metrics.Logins.Inc("success")
 		ctx.JSON(http.StatusOK, gin.H{
 			"token": token,
 		})
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
 	"Praiseson6065/Hypergro-assign/models"
 	"net/http"
 
@@ -14,29 +15,29 @@ type UserSignupRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func UserSignup() gin.HandlerFunc {
+func UserSignup(repo database.UserRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var userSignupRequest UserSignupRequest
 
 		if err := ctx.ShouldBindBodyWithJSON(&userSignupRequest); err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			ctx.Error(middleware.InvalidBody(err))
 			return
 		}
 
 		hashedPwd := hashAndSalt(userSignupRequest.Password)
 
-		id, err := database.FirstOrCreateUser(ctx, &models.User{
+		id, err := repo.FirstOrCreate(ctx, &models.User{
 			Name:     userSignupRequest.Name,
 			Email:    userSignupRequest.Email,
 			Password: hashedPwd,
 		})
 
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			ctx.Error(err)
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"status": "Successfully signed up", "userId": id,})
+		ctx.JSON(http.StatusOK, gin.H{"status": "Successfully signed up", "userId": id})
 
 	}
 }
@@ -0,0 +1,85 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollaboratorRequest struct {
+	UserID string `json:"userId" binding:"required"`
+}
+
+// AddCollaborator handles POST /api/users/{userId}/collections/{collectionId}/collaborators requests
+func AddCollaborator(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		var request CollaboratorRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(apperror.Validation("collaborator_id_required", "Collaborator user ID is required"))
+			return
+		}
+
+		err := repo.AddCollaborator(ctx, userId, ctx.Param("collectionId"), request.UserID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Collaborator added",
+		})
+	}
+}
+
+// RemoveCollaborator handles DELETE /api/users/{userId}/collections/{collectionId}/collaborators/{collaboratorId} requests
+func RemoveCollaborator(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		err := repo.RemoveCollaborator(ctx, ownerId, actorId, ctx.Param("collectionId"), ctx.Param("collaboratorId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Collaborator removed",
+		})
+	}
+}
+
+// ListSharedWithMe handles GET /api/me/shared-collections requests
+func ListSharedWithMe(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId := middleware.GetUserID(ctx)
+		if userId == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		collections, err := repo.ListSharedWithUser(ctx, userId)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":      "success",
+			"count":       len(collections),
+			"collections": collections,
+		})
+	}
+}
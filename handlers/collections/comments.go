@@ -0,0 +1,92 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type ReactionRequest struct {
+	Value string `json:"value"`
+}
+
+// AddComment handles POST /api/users/{userId}/collections/{collectionId}/items/{propId}/comments requests
+func AddComment(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		var request CommentRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(database.ErrCommentRequired)
+			return
+		}
+
+		comment, err := repo.AddItemComment(ctx, ownerId, actorId, ctx.Param("collectionId"), ctx.Param("propId"), request.Text)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"status":  "success",
+			"comment": comment,
+		})
+	}
+}
+
+// DeleteComment handles DELETE /api/users/{userId}/collections/{collectionId}/items/{propId}/comments/{commentId} requests
+func DeleteComment(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		err := repo.DeleteItemComment(ctx, ownerId, actorId, ctx.Param("collectionId"), ctx.Param("propId"), ctx.Param("commentId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Comment deleted",
+		})
+	}
+}
+
+// SetReaction handles PUT /api/users/{userId}/collections/{collectionId}/items/{propId}/reaction requests
+func SetReaction(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		var request ReactionRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(middleware.InvalidBody(err))
+			return
+		}
+
+		err := repo.SetItemReaction(ctx, ownerId, actorId, ctx.Param("collectionId"), ctx.Param("propId"), request.Value)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Reaction saved",
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateCollection handles POST /api/users/{userId}/collections requests
+func CreateCollection(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		var request CollectionRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(database.ErrCollectionNameRequired)
+			return
+		}
+
+		collection, err := repo.CreateCollection(ctx, userId, request.Name)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"status":     "success",
+			"message":    "Collection created successfully",
+			"collection": collection,
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteCollection handles DELETE /api/users/{userId}/collections/{collectionId} requests
+func DeleteCollection(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		err := repo.DeleteCollection(ctx, userId, ctx.Param("collectionId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Collection deleted successfully",
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCollection handles GET /api/users/{userId}/collections/{collectionId} requests
+func GetCollection(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		collection, err := repo.GetCollection(ctx, ownerId, actorId, ctx.Param("collectionId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		items, err := repo.GetCollectionItems(ctx, collection)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		items = hideDrafts(collection, items, actorId)
+
+		// Notes and the share link stay private to the owner
+		if actorId != ownerId {
+			collection.ShareToken = ""
+			for i := range collection.Items {
+				collection.Items[i].Note = ""
+			}
+			for i := range items {
+				items[i].Note = ""
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"collection": collection,
+			"count":      len(items),
+			"items":      items,
+		})
+	}
+}
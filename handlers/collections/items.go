@@ -0,0 +1,94 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ItemRequest struct {
+	PropertyID string `json:"propertyId" binding:"required"`
+	Note       string `json:"note"`
+}
+
+type NoteRequest struct {
+	Note string `json:"note"`
+}
+
+// AddItem handles POST /api/users/{userId}/collections/{collectionId}/items requests
+func AddItem(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		var request ItemRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
+			return
+		}
+
+		err := repo.AddCollectionItem(ctx, ownerId, actorId, ctx.Param("collectionId"), request.PropertyID, request.Note)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Property added to collection",
+		})
+	}
+}
+
+// UpdateItemNote handles PUT /api/users/{userId}/collections/{collectionId}/items/{propId} requests
+func UpdateItemNote(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		var request NoteRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(middleware.InvalidBody(err))
+			return
+		}
+
+		err := repo.UpdateCollectionItemNote(ctx, userId, ctx.Param("collectionId"), ctx.Param("propId"), request.Note)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Note updated",
+		})
+	}
+}
+
+// RemoveItem handles DELETE /api/users/{userId}/collections/{collectionId}/items/{propId} requests
+func RemoveItem(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerId, actorId, ok := authorizeMember(ctx)
+		if !ok {
+			return
+		}
+
+		err := repo.RemoveCollectionItem(ctx, ownerId, actorId, ctx.Param("collectionId"), ctx.Param("propId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Property removed from collection",
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCollections handles GET /api/users/{userId}/collections requests
+func ListCollections(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		collections, err := repo.ListCollections(ctx, userId)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":      "success",
+			"count":       len(collections),
+			"collections": collections,
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShareLink handles POST /api/users/{userId}/collections/{collectionId}/share-link requests
+func CreateShareLink(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		token, err := repo.CreateShareLink(ctx, userId, ctx.Param("collectionId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"shareToken": token,
+			"path":       "/api/shared/collections/" + token,
+		})
+	}
+}
+
+// RevokeShareLink handles DELETE /api/users/{userId}/collections/{collectionId}/share-link requests
+func RevokeShareLink(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		err := repo.RevokeShareLink(ctx, userId, ctx.Param("collectionId"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Share link revoked",
+		})
+	}
+}
+
+// GetSharedCollection handles GET /api/shared/collections/{token} requests.
+// The read-only view leaves out private notes, comments and member IDs.
+func GetSharedCollection(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		collection, err := repo.GetSharedCollection(ctx, ctx.Param("token"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		items, err := repo.GetCollectionItems(ctx, collection)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		// Anyone holding the link may read it, so no one's drafts are shown
+		items = hideDrafts(collection, items, "")
+
+		sharedItems := make([]gin.H, 0, len(items))
+		for _, item := range items {
+			sharedItems = append(sharedItems, gin.H{
+				"property":   item.Property,
+				"savedAt":    item.SavedAt,
+				"thumbsUp":   item.ThumbsUp,
+				"thumbsDown": item.ThumbsDown,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"collection": gin.H{
+				"name":      collection.Name,
+				"updatedAt": collection.UpdatedAt,
+			},
+			"count": len(sharedItems),
+			"items": sharedItems,
+		})
+	}
+}
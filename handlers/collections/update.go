@@ -0,0 +1,36 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenameCollection handles PUT /api/users/{userId}/collections/{collectionId} requests
+func RenameCollection(repo database.FavoriteRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, ok := authorizeOwner(ctx)
+		if !ok {
+			return
+		}
+
+		var request CollectionRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.Error(database.ErrCollectionNameRequired)
+			return
+		}
+
+		collection, err := repo.RenameCollection(ctx, userId, ctx.Param("collectionId"), request.Name)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"message":    "Collection updated successfully",
+			"collection": collection,
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package collections
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// authorizeOwner checks that the authenticated user is the owner named in the URL
+func authorizeOwner(ctx *gin.Context) (string, bool) {
+	userId := ctx.Param("userId")
+	if userId == "" {
+		ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
+		return "", false
+	}
+
+	if middleware.GetUserID(ctx) != userId {
+		ctx.Error(apperror.Forbidden("not_owner", "You can only manage your own collections"))
+		return "", false
+	}
+
+	return userId, true
+}
+
+// authorizeMember requires an authenticated caller and returns the owner from the URL
+// along with the caller. Membership itself is enforced by the data layer, which
+// reports collections the caller cannot see as not found.
+func authorizeMember(ctx *gin.Context) (string, string, bool) {
+	ownerId := ctx.Param("userId")
+	if ownerId == "" {
+		ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
+		return "", "", false
+	}
+
+	actorId := middleware.GetUserID(ctx)
+	if actorId == "" {
+		ctx.Error(middleware.ErrNotAuthenticated)
+		return "", "", false
+	}
+
+	return ownerId, actorId, true
+}
+
+// hideDrafts drops the items that are someone else's draft from a collection and
+// its resolved items, as drafts are only shown to their owner
+func hideDrafts(collection *models.FavoriteCollection, items []models.FavoriteItemDetail, viewerId string) []models.FavoriteItemDetail {
+	visible := make([]models.FavoriteItemDetail, 0, len(items))
+	hidden := make(map[primitive.ObjectID]bool)
+	for _, item := range items {
+		if item.Property.VisibleTo(viewerId) {
+			visible = append(visible, item)
+		} else {
+			hidden[item.Property.ID] = true
+		}
+	}
+	if len(hidden) == 0 {
+		return visible
+	}
+
+	kept := make([]models.FavoriteItem, 0, len(collection.Items))
+	for _, item := range collection.Items {
+		if !hidden[item.PropertyID] {
+			kept = append(kept, item)
+		}
+	}
+	collection.Items = kept
+
+	return visible
+}
@@ -1,6 +1,7 @@
 package favorites
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
 	"net/http"
@@ -9,23 +10,19 @@ import (
 )
 
 // AddFavorite handles POST /api/users/{userId}/favorites requests
-func AddFavorite() gin.HandlerFunc {
+func AddFavorite(repo database.FavoriteRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get userId from URL param
 		userId := ctx.Param("userId")
 		if userId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "User ID is required",
-			})
+			ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
 			return
 		}
 
 		// Verify the authenticated user matches the requested user ID
 		authenticatedUserID := middleware.GetUserID(ctx)
 		if authenticatedUserID != userId {
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"error": "You can only modify your own favorites",
-			})
+			ctx.Error(apperror.Forbidden("not_owner", "You can only modify your own favorites"))
 			return
 		}
 
@@ -34,18 +31,14 @@ func AddFavorite() gin.HandlerFunc {
 			PropertyID string `json:"propertyId"`
 		}
 		if err := ctx.ShouldBindJSON(&request); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Property ID is required",
-			})
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
 			return
 		}
 
 		// Add property to favorites
-		err := database.AddFavoriteProperty(ctx, userId, request.PropertyID)
+		err := repo.AddFavorite(ctx, userId, request.PropertyID)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
@@ -54,4 +47,4 @@ func AddFavorite() gin.HandlerFunc {
 			"message": "Property added to favorites",
 		})
 	}
-}
\ No newline at end of file
+}
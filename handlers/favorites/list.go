@@ -1,47 +1,51 @@
 package favorites
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ListUserFavorites handles GET /api/users/{userId}/favorites requests
-func ListUserFavorites() gin.HandlerFunc {
+func ListUserFavorites(repo database.FavoriteRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get userId from URL param
 		userId := ctx.Param("userId")
 		if userId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "User ID is required",
-			})
+			ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
 			return
 		}
 
 		// Verify the authenticated user matches the requested user ID (for security)
 		authenticatedUserID := middleware.GetUserID(ctx)
 		if authenticatedUserID != userId {
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"error": "You can only view your own favorites",
-			})
+			ctx.Error(apperror.Forbidden("not_owner", "You can only view your own favorites"))
 			return
 		}
 
 		// Get user's favorite properties
-		favorites, err := database.GetUserFavorites(ctx, userId)
+		favorites, err := repo.ListFavorites(ctx, userId)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
+		// Drafts saved before they were refused are still only shown to their owner
+		visible := make([]models.Property, 0, len(favorites))
+		for _, property := range favorites {
+			if property.VisibleTo(userId) {
+				visible = append(visible, property)
+			}
+		}
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"status":    "success",
-			"count":     len(favorites),
-			"favorites": favorites,
+			"count":     len(visible),
+			"favorites": visible,
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package favorites
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
 	"net/http"
@@ -9,41 +10,33 @@ import (
 )
 
 // RemoveFavorite handles DELETE /api/users/{userId}/favorites/{propId} requests
-func RemoveFavorite() gin.HandlerFunc {
+func RemoveFavorite(repo database.FavoriteRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get userId and propId from URL params
 		userId := ctx.Param("userId")
 		propId := ctx.Param("propId")
 
 		if userId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "User ID is required",
-			})
+			ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
 			return
 		}
 
 		if propId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Property ID is required",
-			})
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
 			return
 		}
 
 		// Verify the authenticated user matches the requested user ID
 		authenticatedUserID := middleware.GetUserID(ctx)
 		if authenticatedUserID != userId {
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"error": "You can only modify your own favorites",
-			})
+			ctx.Error(apperror.Forbidden("not_owner", "You can only modify your own favorites"))
 			return
 		}
 
 		// Remove property from favorites
-		err := database.RemoveFavoriteProperty(ctx, userId, propId)
+		err := repo.RemoveFavorite(ctx, userId, propId)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
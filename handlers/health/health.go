@@ -0,0 +1,137 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status values reported by the probes
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check pings one dependency and returns an error when it is unavailable
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Probe runs the readiness checks and tracks whether the server is draining
+type Probe struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func NewProbe(timeout time.Duration) *Probe {
+	return &Probe{timeout: timeout}
+}
+
+// Register adds a dependency to the readiness checks. Call it before serving requests.
+func (p *Probe) Register(name string, check Check) {
+	p.checks = append(p.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes readiness fail from now on, so load balancers stop
+// routing new requests while in-flight ones finish
+func (p *Probe) SetShuttingDown() {
+	p.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether SetShuttingDown has been called
+func (p *Probe) ShuttingDown() bool {
+	return p.shuttingDown.Load()
+}
+
+// Run checks every dependency concurrently, each bounded by the probe timeout
+func (p *Probe) Run(ctx context.Context) map[string]CheckResult {
+	results := make(map[string]CheckResult, len(p.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, c := range p.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.check(checkCtx)
+			result := CheckResult{
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[c.name] = result
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Liveness handles GET /healthz requests. It only shows the process is serving;
+// dependencies are left to readiness so an outage does not get the container restarted.
+func Liveness() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{
+			"status": StatusOK,
+		})
+	}
+}
+
+// Readiness handles GET /readyz requests, answering 503 while any dependency is
+// down or the server is shutting down
+func Readiness(probe *Probe) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if probe.ShuttingDown() {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": StatusShuttingDown,
+			})
+			return
+		}
+
+		checks := probe.Run(ctx.Request.Context())
+
+		status := StatusOK
+		code := http.StatusOK
+		for _, result := range checks {
+			if result.Status != StatusUp {
+				status = StatusDegraded
+				code = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		ctx.JSON(code, gin.H{
+			"status": status,
+			"checks": checks,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListNotifications handles GET /api/me/notifications requests
+func ListNotifications(repo database.NotificationRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		unreadOnly := ctx.Query("unread") == "true"
+
+		var limit int64
+		if limitParam := ctx.Query("limit"); limitParam != "" {
+			parsed, err := strconv.ParseInt(limitParam, 10, 64)
+			if err == nil {
+				limit = parsed
+			}
+		}
+
+		notifications, err := repo.List(ctx, userID, unreadOnly, limit)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":        "success",
+			"count":         len(notifications),
+			"notifications": notifications,
+		})
+	}
+}
+
+// MarkRead handles POST /api/me/notifications/{id}/read requests
+func MarkRead(repo database.NotificationRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		err := repo.MarkRead(ctx, userID, ctx.Param("id"))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Notification marked as read",
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+	notify "Praiseson6065/Hypergro-assign/notifications"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreferences handles GET /api/me/notification-preferences requests
+func GetPreferences(repo database.UserRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		preferences, err := repo.GetNotificationPreferences(ctx, userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":      "success",
+			"preferences": preferences,
+		})
+	}
+}
+
+// UpdatePreferences handles PUT /api/me/notification-preferences requests
+func UpdatePreferences(repo database.UserRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		var preferences models.NotificationPreferences
+		if err := ctx.ShouldBindJSON(&preferences); err != nil {
+			ctx.Error(middleware.InvalidBody(err))
+			return
+		}
+
+		if message := validatePreferences(preferences); message != "" {
+			ctx.Error(apperror.Validation("invalid_preferences", message))
+			return
+		}
+
+		if err := repo.UpdateNotificationPreferences(ctx, userID, preferences); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":      "success",
+			"preferences": preferences,
+		})
+	}
+}
+
+func validatePreferences(preferences models.NotificationPreferences) string {
+	for _, channel := range preferences.Channels {
+		switch channel {
+		case models.ChannelInApp, models.ChannelEmail:
+		case models.ChannelWebhook:
+			parsed, err := url.Parse(preferences.WebhookURL)
+			if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				return "webhook channel requires an https webhookUrl"
+			}
+			// Names are checked again when they are resolved for each delivery
+			if !publicHost(parsed.Hostname()) {
+				return "webhookUrl must point to a public internet host"
+			}
+		default:
+			return "unknown notification channel: " + channel
+		}
+	}
+
+	return ""
+}
+
+// publicHost rejects hosts that are plainly on the server's own network
+func publicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return notify.IsPublicIP(ip)
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAlsoSaved handles GET /api/properties/{id}/also-saved requests
+func ListAlsoSaved(repo database.RecommendationRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		propertyID := ctx.Param("id")
+		if propertyID == "" {
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
+			return
+		}
+
+		alsoSaved, err := repo.GetAlsoSaved(ctx, propertyID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"count":      len(alsoSaved),
+			"properties": alsoSaved,
+		})
+	}
+}
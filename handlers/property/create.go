@@ -2,6 +2,7 @@ package property
 
 import (
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
 	"Praiseson6065/Hypergro-assign/models"
 	"net/http"
 	"time"
@@ -10,39 +11,32 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func CreateProperty() gin.HandlerFunc {
+func CreateProperty(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userID, exists := ctx.Get("userId")
 		if !exists {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			ctx.Error(middleware.ErrNotAuthenticated)
 			return
 		}
-		var propertyRequest models.Property
-		if err := ctx.ShouldBindJSON(&propertyRequest); err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+		var propertyRequest models.PropertyCreateRequest
+		if err := bindProperty(ctx, &propertyRequest); err != nil {
+			ctx.Error(err)
 			return
 		}
 		userObjID, err := primitive.ObjectIDFromHex(userID.(string))
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid user ID format",
-			})
+			ctx.Error(database.ErrInvalidUserID)
 			return
 		}
 
-		propertyRequest.CreatedBy = userObjID
-		propertyRequest.CreatedAt = time.Now()
-
-		createdProperty, err := database.CreateAProperty(ctx, &propertyRequest)
+		property := propertyRequest.Property(userObjID, time.Now())
+		createdProperty, err := repo.Create(ctx, &property)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
+		setETag(ctx, createdProperty)
 		ctx.JSON(http.StatusCreated, gin.H{
 			"status":  "success",
 			"message": "Property created successfully",
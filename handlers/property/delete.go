@@ -8,16 +8,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func DeleteProperty() gin.HandlerFunc {
+func DeleteProperty(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		propertyID := ctx.Param("id")
 
 		userID := middleware.GetUserID(ctx)
 
-		err := database.DeleteAProperty(ctx, propertyID, userID)
+		version, err := ifMatchVersion(ctx, repo, propertyID)
 		if err != nil {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			ctx.Error(err)
+			return
+		}
+
+		err = repo.Delete(ctx, userID, propertyID, version)
+		if err != nil {
+			ctx.Error(err)
 			return
 		}
 
@@ -0,0 +1,99 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrIfMatchRequired rejects writes that do not say which version they change
+var ErrIfMatchRequired = apperror.New(apperror.ErrPreconditionRequired, "if_match_required",
+	"Send the property's ETag in If-Match, so concurrent edits are not overwritten")
+
+// propertyETag is the strong entity tag of a property, its quoted version
+func propertyETag(property *models.Property) string {
+	return `"` + strconv.FormatInt(property.Version, 10) + `"`
+}
+
+// setETag sends the property's entity tag with the response
+func setETag(ctx *gin.Context, property *models.Property) {
+	ctx.Header("ETag", propertyETag(property))
+}
+
+// ifMatchVersion returns the version named by the If-Match header, or AnyVersion
+// for "*". The header is required. A list of tags matches when any of them names
+// the current version, so only then is the property read to pick it. Weak or
+// malformed tags can never match a version, so they fail the precondition.
+func ifMatchVersion(ctx *gin.Context, repo database.PropertyRepository, propertyID string) (int64, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		return 0, ErrIfMatchRequired
+	}
+	if header == "*" {
+		return database.AnyVersion, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || version < 1 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, database.ErrVersionMismatch
+	case 1:
+		return versions[0], nil
+	}
+
+	current, err := repo.GetLatest(ctx, propertyID)
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if version == current.Version {
+			return version, nil
+		}
+	}
+	return 0, database.ErrVersionMismatch
+}
+
+// optionalIfMatchVersion is ifMatchVersion for writes that are safe without
+// If-Match, returning AnyVersion when the header is missing
+func optionalIfMatchVersion(ctx *gin.Context, repo database.PropertyRepository, propertyID string) (int64, error) {
+	if strings.TrimSpace(ctx.GetHeader("If-Match")) == "" {
+		return database.AnyVersion, nil
+	}
+	return ifMatchVersion(ctx, repo, propertyID)
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches the property,
+// using the weak comparison RFC 9110 specifies for it
+func ifNoneMatch(ctx *gin.Context, property *models.Property) bool {
+	header := strings.TrimSpace(ctx.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	etag := propertyETag(property)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,20 +2,21 @@ package property
 
 import (
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetUserProperties() gin.HandlerFunc {
+// GetUserProperties handles GET /api/me/properties requests. Owners see their
+// listings in every status, so drafts and archived listings can be found again.
+func GetUserProperties(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
-		properties, err := database.GetAllPropertiesByUser(ctx)
+		properties, err := repo.ListByOwner(ctx, middleware.GetUserID(ctx))
 
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
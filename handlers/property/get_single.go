@@ -1,27 +1,42 @@
 package property
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/views"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetProperty() gin.HandlerFunc {
+func GetProperty(properties database.PropertyRepository, recorder *views.Recorder) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		propertyID := ctx.Param("id")
 		if propertyID == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Property ID is required",
-			})
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
 			return
 		}
 
-		property, err := database.GetPropertyByID(ctx, propertyID)
+		property, err := properties.GetByID(ctx, propertyID)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
+			return
+		}
+		if !property.VisibleTo(middleware.GetUserID(ctx)) {
+			ctx.Error(database.ErrPropertyNotFound)
+			return
+		}
+
+		// Remember the view for signed-in users so it can feed their recommendations;
+		// it is written in the background, after the response
+		if userID := middleware.GetUserID(ctx); userID != "" {
+			recorder.Record(userID, propertyID)
+		}
+
+		setETag(ctx, property)
+		if ifNoneMatch(ctx, property) {
+			ctx.Status(http.StatusNotModified)
 			return
 		}
 
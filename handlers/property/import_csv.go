@@ -1,7 +1,9 @@
 package property
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/metrics"
 	"Praiseson6065/Hypergro-assign/middleware"
 	"Praiseson6065/Hypergro-assign/models"
 	"encoding/csv"
@@ -10,36 +12,37 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ImportPropertiesFromCSV handles the import of properties from a CSV file
-func ImportPropertiesFromCSV() gin.HandlerFunc {
+func ImportPropertiesFromCSV(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userID := middleware.GetUserID(ctx)
 
 		userObjID, err := primitive.ObjectIDFromHex(userID)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+			ctx.Error(database.ErrInvalidUserID)
 			return
 		}
 
 		file, err := ctx.FormFile("properties_csv")
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded or invalid form field"})
+			ctx.Error(apperror.Validation("file_required", "No file uploaded or invalid form field"))
 			return
 		}
 
 		if !strings.HasSuffix(file.Filename, ".csv") {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only CSV files are allowed"})
+			ctx.Error(apperror.Validation("invalid_file_type", "Only CSV files are allowed"))
 			return
 		}
 
 		openedFile, err := file.Open()
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not open the uploaded file"})
+			ctx.Error(fmt.Errorf("open uploaded file: %w", err))
 			return
 		}
 		defer openedFile.Close()
@@ -47,12 +50,12 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 		reader := csv.NewReader(openedFile)
 		records, err := reader.ReadAll()
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Could not parse CSV file: " + err.Error()})
+			ctx.Error(apperror.Validation("invalid_csv", "Could not parse CSV file: "+err.Error()))
 			return
 		}
 
 		if len(records) < 2 {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "CSV file has insufficient data"})
+			ctx.Error(apperror.Validation("invalid_csv", "CSV file has insufficient data"))
 			return
 		}
 
@@ -66,9 +69,7 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 		requiredFields := []string{"title", "type", "price", "state", "city"}
 		for _, field := range requiredFields {
 			if _, exists := headerMap[field]; !exists {
-				ctx.JSON(http.StatusBadRequest, gin.H{
-					"error": fmt.Sprintf("CSV is missing required field: %s", field),
-				})
+				ctx.Error(apperror.Validation("invalid_csv", fmt.Sprintf("CSV is missing required field: %s", field)))
 				return
 			}
 		}
@@ -76,6 +77,8 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 		var createdProperties []models.Property
 		var errorMessages []string
 
+		start := time.Now()
+
 		for i, record := range records[1:] {
 
 			property := models.Property{
@@ -93,6 +96,10 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 					errorMessages = append(errorMessages, fmt.Sprintf("Row %d: Title is required", rowNum))
 					continue
 				}
+				if strings.ContainsFunc(property.Title, unicode.IsControl) {
+					errorMessages = append(errorMessages, fmt.Sprintf("Row %d: Title must be a single line", rowNum))
+					continue
+				}
 			}
 
 			if idx, exists := headerMap["type"]; exists && idx < len(record) {
@@ -210,7 +217,7 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 				property.ListingType = strings.TrimSpace(record[idx])
 			}
 
-			createdProperty, err := database.CreateAProperty(ctx, &property)
+			createdProperty, err := repo.Create(ctx, &property)
 			if err != nil {
 				errorMessages = append(errorMessages, fmt.Sprintf("Row %d: Failed to create property: %s", rowNum, err.Error()))
 				continue
@@ -219,12 +226,12 @@ func ImportPropertiesFromCSV() gin.HandlerFunc {
 			createdProperties = append(createdProperties, *createdProperty)
 		}
 
-		if len(errorMessages) > 0 && len(createdProperties) == 0 {
+		metrics.ImportedProperties.Add(float64(len(createdProperties)), "created")
+		metrics.ImportedProperties.Add(float64(len(records)-1-len(createdProperties)), "failed")
+		metrics.ImportDuration.Observe(time.Since(start).Seconds())
 
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error":   "Failed to import any properties",
-				"details": errorMessages,
-			})
+		if len(errorMessages) > 0 && len(createdProperties) == 0 {
+			ctx.Error(apperror.Validation("import_failed", "Failed to import any properties: "+strings.Join(errorMessages, "; ")))
 			return
 		}
 
@@ -0,0 +1,425 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrPatchTestFailed is returned when a JSON Patch test operation does not match
+var ErrPatchTestFailed = apperror.Conflict("patch_test_failed", "A test operation in the patch did not match the property")
+
+// patchOperation is one operation of a JSON Patch
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// patchPointer is a JSON Pointer into a property: a field, and for array fields
+// optionally an element index or "-" for the end of the array
+type patchPointer struct {
+	field string
+	index string
+}
+
+// fieldChange records how a patch changed one field
+type fieldChange struct {
+	appended  []interface{}
+	removed   []interface{}
+	rewritten bool
+}
+
+// propertyPatch applies JSON Patch operations to a JSON copy of a property,
+// tracking what changed so the result can be written with atomic operators
+type propertyPatch struct {
+	doc     map[string]interface{}
+	changes map[string]*fieldChange
+	tested  map[string]bool
+}
+
+// jsonPatchUpdate translates a JSON Patch. The operations are applied in order to
+// the current property, then each changed field becomes one update operator:
+// appends to an array become $addToSet, removals from it become $pull, and any
+// other change sets the field. Fields set from the current property, and fields
+// named by test operations, must be unchanged when the update is written. The
+// patch is applied to the version named by If-Match, so a client patching a
+// version it has not seen gets 412 rather than a patch applied to other data.
+func jsonPatchUpdate(ctx *gin.Context, repo database.PropertyRepository, propertyID string, version int64) (database.PropertyUpdate, error) {
+	var update database.PropertyUpdate
+
+	var operations []patchOperation
+	if err := ctx.ShouldBindBodyWithJSON(&operations); err != nil {
+		return update, middleware.InvalidBody(err)
+	}
+
+	// Read past the cache, so test operations are checked against the stored property
+	current, err := repo.GetLatest(ctx, propertyID)
+	if err != nil {
+		return update, err
+	}
+	if version != database.AnyVersion && current.Version != version {
+		return update, database.ErrVersionMismatch
+	}
+
+	patch, err := newPropertyPatch(current)
+	if err != nil {
+		return update, err
+	}
+	for _, operation := range operations {
+		if err := patch.apply(operation); err != nil {
+			return update, err
+		}
+	}
+
+	return patch.update(current)
+}
+
+func newPropertyPatch(property *models.Property) (*propertyPatch, error) {
+	raw, err := json.Marshal(property)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return &propertyPatch{
+		doc:     doc,
+		changes: make(map[string]*fieldChange),
+		tested:  make(map[string]bool),
+	}, nil
+}
+
+func (p *propertyPatch) apply(operation patchOperation) error {
+	path, err := parsePatchPointer(operation.Path)
+	if err != nil {
+		return err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return invalidPatch("%s requires a value", operation.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return invalidPatch("invalid value: %v", err)
+		}
+
+		switch operation.Op {
+		case "add":
+			return p.add(path, value)
+		case "replace":
+			if err := p.remove(path, true); err != nil {
+				return err
+			}
+			return p.add(path, value)
+		default:
+			return p.test(path, value)
+		}
+	case "remove":
+		return p.remove(path, false)
+	case "move", "copy":
+		from, err := parsePatchPointer(operation.From)
+		if err != nil {
+			return err
+		}
+		value, err := p.get(from)
+		if err != nil {
+			return err
+		}
+		value = copyJSON(value)
+		if operation.Op == "move" {
+			if err := p.remove(from, true); err != nil {
+				return err
+			}
+		}
+		return p.add(path, value)
+	default:
+		return invalidPatch("unknown op %q", operation.Op)
+	}
+}
+
+func (p *propertyPatch) get(path patchPointer) (interface{}, error) {
+	value, exists := p.doc[path.field]
+	if path.index == "" {
+		if !exists {
+			return nil, invalidPatch("%s is not set", path.field)
+		}
+		return value, nil
+	}
+
+	array := p.array(path.field)
+	i, err := elementIndex(path, len(array)-1)
+	if err != nil {
+		return nil, err
+	}
+	return array[i], nil
+}
+
+func (p *propertyPatch) add(path patchPointer, value interface{}) error {
+	change := p.change(path.field)
+	if path.index == "" {
+		p.doc[path.field] = value
+		change.rewritten = true
+		return nil
+	}
+
+	array := p.array(path.field)
+	if path.index == "-" {
+		p.doc[path.field] = append(array, value)
+		change.appended = append(change.appended, value)
+		return nil
+	}
+
+	i, err := elementIndex(path, len(array))
+	if err != nil {
+		return err
+	}
+	array = append(array, nil)
+	copy(array[i+1:], array[i:])
+	array[i] = value
+	p.doc[path.field] = array
+	change.rewritten = true
+	return nil
+}
+
+// remove deletes the value at path. Removals that are part of a replace or move
+// rewrite the field rather than pulling a value from it.
+func (p *propertyPatch) remove(path patchPointer, rewrite bool) error {
+	change := p.change(path.field)
+	if path.index == "" {
+		if _, exists := p.doc[path.field]; !exists {
+			return invalidPatch("%s is not set", path.field)
+		}
+		if !rewrite && requiredFields[path.field] {
+			return apperror.InvalidFields("invalid_patch", "The patch removes required fields", []apperror.FieldError{{
+				Field: path.field, Message: "is required and cannot be removed",
+			}})
+		}
+		delete(p.doc, path.field)
+		change.rewritten = true
+		return nil
+	}
+
+	array := p.array(path.field)
+	i, err := elementIndex(path, len(array)-1)
+	if err != nil {
+		return err
+	}
+	if rewrite {
+		change.rewritten = true
+	} else {
+		change.removed = append(change.removed, array[i])
+	}
+	p.doc[path.field] = append(array[:i:i], array[i+1:]...)
+	return nil
+}
+
+func (p *propertyPatch) test(path patchPointer, value interface{}) error {
+	p.tested[path.field] = true
+
+	current, err := p.get(path)
+	if err != nil {
+		return ErrPatchTestFailed
+	}
+	if !reflect.DeepEqual(current, value) {
+		return ErrPatchTestFailed
+	}
+	return nil
+}
+
+// array returns an array field, treating an unset field as empty
+func (p *propertyPatch) array(field string) []interface{} {
+	array, _ := p.doc[field].([]interface{})
+	return array
+}
+
+func (p *propertyPatch) change(field string) *fieldChange {
+	change, ok := p.changes[field]
+	if !ok {
+		change = &fieldChange{}
+		p.changes[field] = change
+	}
+	return change
+}
+
+// update builds the atomic update for the patched fields and validates their
+// new values
+func (p *propertyPatch) update(current *models.Property) (database.PropertyUpdate, error) {
+	update := database.PropertyUpdate{
+		AddToSet: make(map[string][]string),
+		Pull:     make(map[string][]string),
+		Expect:   make(map[string]interface{}),
+	}
+
+	stored, err := bsonFields(current)
+	if err != nil {
+		return update, err
+	}
+
+	values := make(map[string]json.RawMessage)
+	var set []string
+	var fields []apperror.FieldError
+	for field, change := range p.changes {
+		// Setting a field to null removes it, as in a merge patch
+		value, exists := p.doc[field]
+		if !exists || value == nil {
+			if requiredFields[field] {
+				fields = append(fields, apperror.FieldError{Field: field, Message: "is required and cannot be removed"})
+				continue
+			}
+			update.Unset = append(update.Unset, field)
+			continue
+		}
+
+		// The new value is validated whichever operator writes it
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return update, err
+		}
+		values[field] = raw
+
+		if arrayFields[field] && !change.rewritten && (len(change.appended) == 0 || len(change.removed) == 0) {
+			if len(change.appended) > 0 {
+				update.AddToSet[field] = jsonStrings(change.appended)
+			}
+			if len(change.removed) > 0 {
+				update.Pull[field] = jsonStrings(change.removed)
+			}
+			continue
+		}
+
+		set = append(set, field)
+		if arrayFields[field] {
+			// A rewritten array is only correct if nobody changed it meanwhile
+			update.Expect[field] = stored[field]
+		}
+	}
+	if len(fields) > 0 {
+		sortFieldErrors(fields)
+		return update, apperror.InvalidFields("invalid_patch", "The patch removes required fields", fields)
+	}
+	sort.Strings(update.Unset)
+
+	request, err := decodeUpdate(values)
+	if err != nil {
+		if apperror.Status(err) == http.StatusBadRequest {
+			return update, apperror.InvalidFields("invalid_patch", "The patch sets invalid values", apperror.Fields(err))
+		}
+		return update, err
+	}
+	update.Set = make(map[string]interface{}, len(set))
+	updates := request.Updates()
+	for _, field := range set {
+		update.Set[field] = updates[field]
+	}
+
+	for field := range p.tested {
+		update.Expect[field] = stored[field]
+	}
+
+	return update, nil
+}
+
+// parsePatchPointer parses a JSON Pointer to a writable property field or to an
+// element of an array field
+func parsePatchPointer(pointer string) (patchPointer, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return patchPointer{}, invalidPatch("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	path := patchPointer{field: tokens[0]}
+	if message := fieldNotWritable(path.field, updatableFields); message != "" {
+		return path, apperror.InvalidFields("invalid_patch", "The patch changes fields that cannot be written", []apperror.FieldError{{
+			Field: path.field, Message: message,
+		}})
+	}
+
+	switch {
+	case len(tokens) == 1:
+	case len(tokens) == 2 && arrayFields[path.field]:
+		path.index = tokens[1]
+	default:
+		return path, invalidPatch("path %q does not name a property field or list element", pointer)
+	}
+	return path, nil
+}
+
+// elementIndex parses the index of path, which must be at most max
+func elementIndex(path patchPointer, max int) (int, error) {
+	i, err := strconv.Atoi(path.index)
+	if err != nil || i < 0 || i > max || (len(path.index) > 1 && path.index[0] == '0') {
+		return 0, invalidPatch("%s has no element %s", path.field, path.index)
+	}
+	return i, nil
+}
+
+func invalidPatch(format string, args ...interface{}) error {
+	return apperror.Validation("invalid_patch", fmt.Sprintf(format, args...))
+}
+
+// bsonFields returns a property's fields as they are stored
+func bsonFields(property *models.Property) (bson.M, error) {
+	raw, err := bson.Marshal(property)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonStrings keeps the strings among JSON values. Other values never reach the
+// store, since the field's new value fails validation first.
+func jsonStrings(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func copyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, element := range v {
+			copied[i] = copyJSON(element)
+		}
+		return copied
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			copied[key] = copyJSON(element)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -2,73 +2,63 @@ package property
 
 import (
 	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
-// ListProperties handles GET /api/properties requests for listing and filtering properties
-func ListProperties() gin.HandlerFunc {
+// ListProperties handles GET /api/properties requests for listing and filtering
+// properties. Only active listings are shown unless another public status is asked for.
+func ListProperties(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// Build filters based on query parameters
-		filters := bson.M{}
-
-		// Handle common property filters
-		if propertyType := ctx.Query("type"); propertyType != "" {
-			filters["type"] = propertyType
-		}
-
-		if city := ctx.Query("city"); city != "" {
-			filters["city"] = city
+		status, err := listingStatus(ctx)
+		if err != nil {
+			ctx.Error(err)
+			return
 		}
 
-		if state := ctx.Query("state"); state != "" {
-			filters["state"] = state
+		// Build the filter from query parameters; malformed numbers are ignored
+		filter := models.PropertyFilter{
+			Type:      ctx.Query("type"),
+			City:      ctx.Query("city"),
+			State:     ctx.Query("state"),
+			Furnished: ctx.Query("furnished"),
+			Status:    status,
 		}
 
 		if minPrice := ctx.Query("minPrice"); minPrice != "" {
 			price, err := strconv.ParseInt(minPrice, 10, 64)
 			if err == nil {
-				filters["price"] = bson.M{"$gte": price}
+				filter.MinPrice = &price
 			}
 		}
 
 		if maxPrice := ctx.Query("maxPrice"); maxPrice != "" {
 			price, err := strconv.ParseInt(maxPrice, 10, 64)
 			if err == nil {
-				if priceFilter, ok := filters["price"].(bson.M); ok {
-					priceFilter["$lte"] = price
-				} else {
-					filters["price"] = bson.M{"$lte": price}
-				}
+				filter.MaxPrice = &price
 			}
 		}
 
 		if bedrooms := ctx.Query("bedrooms"); bedrooms != "" {
 			beds, err := strconv.Atoi(bedrooms)
 			if err == nil {
-				filters["bedrooms"] = beds
+				filter.Bedrooms = &beds
 			}
 		}
 
 		if bathrooms := ctx.Query("bathrooms"); bathrooms != "" {
 			baths, err := strconv.Atoi(bathrooms)
 			if err == nil {
-				filters["bathrooms"] = baths
+				filter.Bathrooms = &baths
 			}
 		}
 
-		if furnished := ctx.Query("furnished"); furnished != "" {
-			filters["furnished"] = furnished
-		}
-
-		properties, err := database.GetAllProperties(ctx, filters)
+		properties, err := repo.List(ctx, filter)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
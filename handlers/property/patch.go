@@ -0,0 +1,121 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Media types accepted by PatchProperty
+const (
+	// MergePatchContentType is a JSON Merge Patch, RFC 7396
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType is a JSON Patch, RFC 6902
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+// ErrUnsupportedPatch rejects patches in any other format
+var ErrUnsupportedPatch = apperror.New(apperror.ErrUnsupportedMediaType, "unsupported_patch_type",
+	"PATCH accepts "+MergePatchContentType+" or "+JSONPatchContentType)
+
+// PatchProperty handles PATCH /api/properties/:id requests. The patch is
+// checked against the same writable fields and rules as PUT, then applied as a
+// single atomic update.
+func PatchProperty(repo database.PropertyRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		propertyID := ctx.Param("id")
+
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		if _, err := primitive.ObjectIDFromHex(propertyID); err != nil {
+			ctx.Error(database.ErrInvalidPropertyID)
+			return
+		}
+
+		version, err := ifMatchVersion(ctx, repo, propertyID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		var update database.PropertyUpdate
+		switch ctx.ContentType() {
+		case MergePatchContentType:
+			update, err = mergePatchUpdate(ctx)
+		case JSONPatchContentType:
+			update, err = jsonPatchUpdate(ctx, repo, propertyID, version)
+		default:
+			ctx.Header("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+			err = ErrUnsupportedPatch
+		}
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if update.IsEmpty() {
+			ctx.Error(ErrNothingToUpdate)
+			return
+		}
+
+		updatedProperty, err := repo.Update(ctx, userID, propertyID, version, update)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		setETag(ctx, updatedProperty)
+		ctx.JSON(http.StatusOK, gin.H{"message": "Property updated successfully", "property": updatedProperty})
+	}
+}
+
+// mergePatchUpdate translates a JSON Merge Patch: members set their field, null
+// members remove it, and arrays are replaced whole
+func mergePatchUpdate(ctx *gin.Context) (database.PropertyUpdate, error) {
+	var update database.PropertyUpdate
+
+	var body map[string]json.RawMessage
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		return update, middleware.InvalidBody(err)
+	}
+	if err := checkWritable(body, updatableFields); err != nil {
+		return update, err
+	}
+
+	values := make(map[string]json.RawMessage, len(body))
+	var fields []apperror.FieldError
+	for name, value := range body {
+		if string(value) != "null" {
+			values[name] = value
+			continue
+		}
+		if requiredFields[name] {
+			fields = append(fields, apperror.FieldError{Field: name, Message: "is required and cannot be removed"})
+			continue
+		}
+		update.Unset = append(update.Unset, name)
+	}
+	if len(fields) > 0 {
+		sortFieldErrors(fields)
+		return update, apperror.InvalidFields("invalid_body", "The request body has invalid fields", fields)
+	}
+	sort.Strings(update.Unset)
+
+	request, err := decodeUpdate(values)
+	if err != nil {
+		return update, err
+	}
+	update.Set = request.Updates()
+
+	return update, nil
+}
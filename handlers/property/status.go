@@ -0,0 +1,140 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNotActive rejects renewing a listing that is not active
+var ErrNotActive = apperror.Conflict("property_not_active", "Only active listings can be renewed")
+
+// publicStatuses are the statuses anyone may list; drafts and archived listings
+// are only shown to their owner
+var publicStatuses = map[string]bool{
+	models.PropertyActive:     true,
+	models.PropertyUnderOffer: true,
+	models.PropertySold:       true,
+	models.PropertyRented:     true,
+}
+
+// TransitionProperty handles POST /api/properties/:id/<action> requests that move
+// a listing to status, e.g. mark-sold. Only the owner may change the status, and
+// only along the transitions models.CanTransition allows. The status the change
+// starts from is checked when it is written, so If-Match is optional here.
+func TransitionProperty(repo database.PropertyRepository, status string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		changeStatus(ctx, repo, func(current *models.Property, now time.Time) (database.PropertyUpdate, error) {
+			if err := checkTransition(current, status); err != nil {
+				return database.PropertyUpdate{}, err
+			}
+
+			set := map[string]interface{}{
+				"status":                            status,
+				models.StatusTimestampField(status): now,
+			}
+			// Listing a property again starts a new expiry period
+			if status == models.PropertyActive {
+				set["renewedAt"] = now
+			}
+			return database.PropertyUpdate{Set: set, Expect: map[string]interface{}{"status": current.Status}}, nil
+		})
+	}
+}
+
+// RenewProperty handles POST /api/properties/:id/renew requests, which keep an
+// active listing from expiring for another period
+func RenewProperty(repo database.PropertyRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		changeStatus(ctx, repo, func(current *models.Property, now time.Time) (database.PropertyUpdate, error) {
+			if current.Status != models.PropertyActive {
+				return database.PropertyUpdate{}, ErrNotActive
+			}
+			return database.PropertyUpdate{
+				Set:    map[string]interface{}{"renewedAt": now},
+				Expect: map[string]interface{}{"status": models.PropertyActive},
+			}, nil
+		})
+	}
+}
+
+// changeStatus writes the update buildUpdate makes from the current property,
+// after checking the caller owns it
+func changeStatus(ctx *gin.Context, repo database.PropertyRepository, buildUpdate func(current *models.Property, now time.Time) (database.PropertyUpdate, error)) {
+	propertyID := ctx.Param("id")
+
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		ctx.Error(middleware.ErrNotAuthenticated)
+		return
+	}
+
+	version, err := optionalIfMatchVersion(ctx, repo, propertyID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	current, err := repo.GetLatest(ctx, propertyID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if current.CreatedBy.Hex() != userID {
+		ctx.Error(database.ErrPropertyNotOwned)
+		return
+	}
+
+	update, err := buildUpdate(current, time.Now())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	updatedProperty, err := repo.Update(ctx, userID, propertyID, version, update)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setETag(ctx, updatedProperty)
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "property": updatedProperty})
+}
+
+// checkTransition reports whether property may move to status. A listing can
+// only be sold or rented as its listing type allows.
+func checkTransition(property *models.Property, status string) error {
+	if !models.CanTransition(property.Status, status) {
+		return invalidTransition("Cannot move a listing from %s to %s", statusName(property.Status), statusName(status))
+	}
+	if (status == models.PropertySold && property.ListingType == "rent") ||
+		(status == models.PropertyRented && property.ListingType == "sale") {
+		return invalidTransition("A listing for %s cannot be marked %s", property.ListingType, statusName(status))
+	}
+	return nil
+}
+
+func invalidTransition(format string, args ...interface{}) error {
+	return apperror.Conflict("invalid_status_transition", fmt.Sprintf(format, args...))
+}
+
+func statusName(status string) string {
+	return strings.ReplaceAll(status, "_", " ")
+}
+
+// listingStatus returns the status a public listing is filtered by, active
+// unless the status query parameter names another public status
+func listingStatus(ctx *gin.Context) (string, error) {
+	status := ctx.DefaultQuery("status", models.PropertyActive)
+	if !publicStatuses[status] {
+		return "", apperror.Validation("invalid_status", "status must be one of active, under_offer, sold or rented")
+	}
+	return status, nil
+}
@@ -1,45 +1,60 @@
 package property
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func UpdateProperty() gin.HandlerFunc {
+// ErrNothingToUpdate rejects updates that set no fields
+var ErrNothingToUpdate = apperror.Validation("nothing_to_update", "The request body has no fields to update")
+
+func UpdateProperty(repo database.PropertyRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		propertyID := ctx.Param("id")
 
 		userID := middleware.GetUserID(ctx)
 		if userID == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			ctx.Error(middleware.ErrNotAuthenticated)
 			return
 		}
 
-		
-		var updateData map[string]interface{}
-		if err := ctx.ShouldBindJSON(&updateData); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		version, err := ifMatchVersion(ctx, repo, propertyID)
+		if err != nil {
+			ctx.Error(err)
 			return
 		}
 
-		objID, err := primitive.ObjectIDFromHex(propertyID)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid property ID format"})
+		var updateRequest models.PropertyUpdateRequest
+		if err := bindProperty(ctx, &updateRequest); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		update := database.PropertyUpdate{Set: updateRequest.Updates()}
+		if update.IsEmpty() {
+			ctx.Error(ErrNothingToUpdate)
 			return
 		}
 
+		if _, err := primitive.ObjectIDFromHex(propertyID); err != nil {
+			ctx.Error(database.ErrInvalidPropertyID)
+			return
+		}
 
-		updatedProperty, err := database.UpdateAProperty(ctx, updateData, objID)
+		updatedProperty, err := repo.Update(ctx, userID, propertyID, version, update)
 		if err != nil {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			ctx.Error(err)
 			return
 		}
 
+		setETag(ctx, updatedProperty)
 		ctx.JSON(http.StatusOK, gin.H{"message": "Property updated successfully", "property": updatedProperty})
 	}
 }
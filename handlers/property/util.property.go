@@ -0,0 +1,140 @@
+package property
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"Praiseson6065/Hypergro-assign/models"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// readOnlyFields are set by the server and cannot be updated by a client. A
+// listing's status only changes through its status actions.
+var readOnlyFields = map[string]bool{
+	"id":           true,
+	"_id":          true,
+	"createdBy":    true,
+	"createdAt":    true,
+	"isVerified":   true,
+	"rating":       true,
+	"version":      true,
+	"status":       true,
+	"publishedAt":  true,
+	"underOfferAt": true,
+	"soldAt":       true,
+	"rentedAt":     true,
+	"archivedAt":   true,
+	"renewedAt":    true,
+}
+
+// requiredFields must be present on every property, so they cannot be removed
+var requiredFields = map[string]bool{
+	"title": true,
+	"type":  true,
+	"price": true,
+	"state": true,
+	"city":  true,
+}
+
+// arrayFields hold lists of strings that patches can edit element by element
+var arrayFields = map[string]bool{
+	"amenities": true,
+	"tags":      true,
+}
+
+// updatableFields are the fields a client may change after creation
+var updatableFields = jsonFields(models.PropertyUpdateRequest{})
+
+// bindProperty binds a JSON body into request, a property request struct, and
+// validates it. Fields the struct does not declare are rejected rather than
+// ignored, and every failing field is reported at once.
+func bindProperty(ctx *gin.Context, request interface{}) error {
+	var body map[string]json.RawMessage
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		return middleware.InvalidBody(err)
+	}
+
+	if err := checkWritable(body, jsonFields(request)); err != nil {
+		return err
+	}
+
+	// The body is cached by the first bind, so it can be bound again
+	if err := ctx.ShouldBindBodyWithJSON(request); err != nil {
+		return middleware.InvalidBody(err)
+	}
+	return nil
+}
+
+// checkWritable rejects read-only fields and fields that are not in writable
+func checkWritable(body map[string]json.RawMessage, writable map[string]bool) error {
+	var fields []apperror.FieldError
+	for name := range body {
+		if message := fieldNotWritable(name, writable); message != "" {
+			fields = append(fields, apperror.FieldError{Field: name, Message: message})
+		}
+	}
+	if len(fields) > 0 {
+		sortFieldErrors(fields)
+		return apperror.InvalidFields("invalid_body", "The request body has fields that cannot be written", fields)
+	}
+	return nil
+}
+
+// fieldNotWritable explains why a field cannot be written, or returns "". A
+// request may still declare a read-only field, as create does for status.
+func fieldNotWritable(name string, writable map[string]bool) string {
+	switch {
+	case writable[name]:
+		return ""
+	case readOnlyFields[name]:
+		return "is read-only"
+	default:
+		return "is not a property field"
+	}
+}
+
+// decodeUpdate decodes and validates the given fields as a property update
+func decodeUpdate(values map[string]json.RawMessage) (models.PropertyUpdateRequest, error) {
+	var request models.PropertyUpdateRequest
+	if len(values) == 0 {
+		return request, nil
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return request, err
+	}
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return request, middleware.InvalidBody(err)
+	}
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		return request, middleware.InvalidBody(err)
+	}
+	return request, nil
+}
+
+func sortFieldErrors(fields []apperror.FieldError) {
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+}
+
+// jsonFields is the set of JSON names of a struct's fields
+func jsonFields(v interface{}) map[string]bool {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
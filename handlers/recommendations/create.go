@@ -1,6 +1,7 @@
 package recommendations
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
 	"net/http"
@@ -8,13 +9,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CreateRecommendation() gin.HandlerFunc {
+func CreateRecommendation(repo database.RecommendationRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		fromUserID := middleware.GetUserID(ctx)
 		if fromUserID == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User not authenticated",
-			})
+			ctx.Error(middleware.ErrNotAuthenticated)
 			return
 		}
 
@@ -23,31 +22,23 @@ func CreateRecommendation() gin.HandlerFunc {
 			PropertyID string `json:"propertyId"`
 		}
 		if err := ctx.ShouldBindJSON(&request); err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid request: " + err.Error(),
-			})
+			ctx.Error(middleware.InvalidBody(err))
 			return
 		}
 
 		if request.ToUserID == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Recipient user ID is required",
-			})
+			ctx.Error(apperror.Validation("recipient_id_required", "Recipient user ID is required"))
 			return
 		}
 
 		if request.PropertyID == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Property ID is required",
-			})
+			ctx.Error(apperror.Validation("property_id_required", "Property ID is required"))
 			return
 		}
 
-		err := database.RecommendProperty(ctx, fromUserID, request.ToUserID, request.PropertyID)
+		err := repo.Recommend(ctx, fromUserID, request.ToUserID, request.PropertyID)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
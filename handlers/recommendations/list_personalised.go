@@ -0,0 +1,33 @@
+package recommendations
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPersonalisedRecommendations handles GET /api/me/recommended requests
+func ListPersonalisedRecommendations(repo database.RecommendationRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := middleware.GetUserID(ctx)
+		if userID == "" {
+			ctx.Error(middleware.ErrNotAuthenticated)
+			return
+		}
+
+		// Served from the batch-computed cache, falling back to computing on demand
+		recommendations, err := repo.GetPersonalised(ctx, userID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"status":          "success",
+			"count":           len(recommendations),
+			"recommendations": recommendations,
+		})
+	}
+}
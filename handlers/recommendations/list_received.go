@@ -1,6 +1,7 @@
 package recommendations
 
 import (
+	"Praiseson6065/Hypergro-assign/apperror"
 	"Praiseson6065/Hypergro-assign/database"
 	"Praiseson6065/Hypergro-assign/middleware"
 	"net/http"
@@ -9,32 +10,26 @@ import (
 )
 
 // ListReceivedRecommendations handles GET /api/users/{userId}/recommendations/received requests
-func ListReceivedRecommendations() gin.HandlerFunc {
+func ListReceivedRecommendations(repo database.RecommendationRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Get userId from URL param
 		userId := ctx.Param("userId")
 		if userId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "User ID is required",
-			})
+			ctx.Error(apperror.Validation("user_id_required", "User ID is required"))
 			return
 		}
 
 		// Verify the authenticated user matches the requested user ID
 		authenticatedUserID := middleware.GetUserID(ctx)
 		if authenticatedUserID != userId {
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"error": "You can only view your own recommendations",
-			})
+			ctx.Error(apperror.Forbidden("not_owner", "You can only view your own recommendations"))
 			return
 		}
 
 		// Get received recommendations
-		recommendations, err := database.GetReceivedRecommendations(ctx, userId)
+		recommendations, err := repo.ListReceived(ctx, userId)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
+			ctx.Error(err)
 			return
 		}
 
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&out, nil)))
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), "user-1")
+	logger.With("component", "test").InfoContext(ctx, "hello", "n", 1)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "req-1", record[RequestIDKey])
+	assert.Equal(t, "user-1", record[UserIDKey])
+	assert.Equal(t, "test", record["component"])
+
+	// The active span is attached so log lines can be matched to traces
+	out.Reset()
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(ctx, spanCtx), "traced")
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, spanCtx.TraceID().String(), record[TraceIDKey])
+	assert.Equal(t, spanCtx.SpanID().String(), record[SpanIDKey])
+
+	// Without IDs in the context no empty attributes are added
+	out.Reset()
+	logger.Info("plain")
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.NotContains(t, out.String(), RequestIDKey)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("DEBUG")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	level, err = ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("loud")
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import "net/http"
+
+// Default holds the metrics of the application, served on the admin port
+var Default = NewRegistry()
+
+var (
+	HTTPRequests = Default.NewCounterVec("http_requests_total",
+		"HTTP requests handled, by method, route template and status code.",
+		"method", "route", "status")
+	HTTPRequestDuration = Default.NewHistogramVec("http_request_duration_seconds",
+		"Time spent handling HTTP requests, by method and route template.",
+		DefaultBuckets, "method", "route")
+
+	MongoOperationDuration = Default.NewHistogramVec("mongodb_operation_duration_seconds",
+		"Latency of MongoDB commands, by collection and operation.",
+		DefaultBuckets, "collection", "operation")
+	MongoOperationErrors = Default.NewCounterVec("mongodb_operation_errors_total",
+		"MongoDB commands that failed, by collection and operation.",
+		"collection", "operation")
+
+	CacheRequests = Default.NewCounterVec("cache_requests_total",
+		"Cache lookups by key prefix and result: hit, miss or stale.",
+		"prefix", "result")
+	CacheEarlyRefreshes = Default.NewCounterVec("cache_early_refreshes_total",
+		"Fresh cache entries refreshed in the background ahead of expiry, by key prefix.",
+		"prefix")
+
+	ImportedProperties = Default.NewCounterVec("import_properties_total",
+		"Rows processed by CSV imports, by result: created or failed.",
+		"result")
+	ImportDuration = Default.NewHistogramVec("import_duration_seconds",
+		"Time taken by CSV imports.",
+		[]float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300})
+
+	Logins = Default.NewCounterVec("auth_logins_total",
+		"Login attempts by result: success or failure.",
+		"result")
+
+	RateLimited = Default.NewCounterVec("http_rate_limited_total",
+		"Requests rejected with 429 Too Many Requests, by rate limit policy.",
+		"policy")
+)
+
+// Handler serves the Default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
@@ -1,19 +1,146 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy says which browser origins may call the API and what they may send
+// and read. An origin is written as scheme://host[:port]; a "*" in place of the
+// leftmost labels, as in https://*.example.com, matches any subdomain, and "*" alone
+// matches every origin. Credentials cannot be allowed together with "*".
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and adds the CORS headers to responses for
+// allowed origins. The matching origin is echoed back rather than "*", so the
+// policy also works for requests made with credentials.
+func CORS(policy CORSPolicy) gin.HandlerFunc {
+	matchers := make([]originMatcher, 0, len(policy.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		matchers = append(matchers, newOriginMatcher(origin))
+	}
+
+	allowedMethods := strings.Join(policy.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(policy.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		for _, m := range matchers {
+			if m.match(origin) {
+				return true
+			}
+		}
+		return false
+	}
 
-func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		header := c.Writer.Header()
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		// Responses depend on the Origin, so shared caches must not mix them up
+		header.Add("Vary", "Origin")
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			c.Next()
 			return
 		}
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// The browser withholds the response from the page without the CORS headers
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !policy.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
 
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			if allowedHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+			if policy.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposedHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", exposedHeaders)
+		}
 		c.Next()
 	}
 }
+
+// originMatcher matches one allowed origin, which may start its host with "*."
+type originMatcher struct {
+	prefix string
+	suffix string
+	exact  bool
+}
+
+func newOriginMatcher(origin string) originMatcher {
+	origin = strings.ToLower(origin)
+	if i := strings.Index(origin, "://*."); i >= 0 {
+		return originMatcher{prefix: origin[:i+3], suffix: origin[i+4:]}
+	}
+	return originMatcher{prefix: origin, exact: true}
+}
+
+func (m originMatcher) match(origin string) bool {
+	origin = strings.ToLower(origin)
+	if m.exact {
+		return origin == m.prefix
+	}
+	if !strings.HasPrefix(origin, m.prefix) || !strings.HasSuffix(origin, m.suffix) {
+		return false
+	}
+
+	// The wildcard stands for one or more host labels, never a port or path
+	labels := origin[len(m.prefix) : len(origin)-len(m.suffix)]
+	if labels == "" || strings.HasPrefix(labels, ".") {
+		return false
+	}
+	for _, r := range labels {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
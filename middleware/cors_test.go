@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(policy CORSPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS(policy))
+	r.GET("/things", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PATCH("/things", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func corsRequest(r *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/things", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	r := newCORSRouter(CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{"GET", "PATCH"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	})
+	preflight := map[string]string{"Access-Control-Request-Method": "PATCH"}
+
+	t.Run("reflects an allowed origin", func(t *testing.T) {
+		w := corsRequest(r, http.MethodGet, "https://app.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("matches wildcard subdomains", func(t *testing.T) {
+		for origin, ok := range map[string]bool{
+			"https://a.example.org":          true,
+			"https://a.b.example.org":        true,
+			"https://example.org":            false,
+			"http://a.example.org":           false,
+			"https://evilexample.org":        false,
+			"https://a.example.org.evil.com": false,
+			"https://a.example.org:8443":     false,
+		} {
+			w := corsRequest(r, http.MethodGet, origin, nil)
+			if ok {
+				assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"), origin)
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+			}
+		}
+	})
+
+	t.Run("answers preflight requests", func(t *testing.T) {
+		w := corsRequest(r, http.MethodOptions, "https://app.example.com", preflight)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "GET, PATCH", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.ElementsMatch(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("refuses preflight from other origins", func(t *testing.T) {
+		w := corsRequest(r, http.MethodOptions, "https://evil.com", preflight)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("serves other origins without CORS headers", func(t *testing.T) {
+		w := corsRequest(r, http.MethodGet, "https://evil.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	w := corsRequest(newCORSRouter(CORSPolicy{AllowedOrigins: []string{"*"}}), http.MethodGet, "https://anywhere.test", nil)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(newCORSRouter(CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}), http.MethodGet, "https://anywhere.test", nil)
+	assert.Equal(t, "https://anywhere.test", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+}
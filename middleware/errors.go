@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"Praiseson6065/Hypergro-assign/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of error responses, defined by RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix makes a problem type URI from an error code
+const problemTypePrefix = "urn:hypergro:problem:"
+
+// Errors raised by the middleware
+var (
+	ErrMissingAuthorization = apperror.Unauthorized("missing_authorization", "Authorization header is required")
+	ErrInvalidAuthorization = apperror.Unauthorized("invalid_authorization", "Authorization header must be a bearer token")
+	ErrInvalidToken         = apperror.Unauthorized("invalid_token", "Token is invalid or has expired")
+	ErrNotAuthenticated     = apperror.Unauthorized("not_authenticated", "User not authenticated")
+	ErrTooManyRequests      = apperror.New(apperror.ErrRateLimited, "rate_limited", "Too many requests, retry later")
+	ErrRouteNotFound        = apperror.NotFound("route_not_found", "No route matches the request")
+)
+
+// Problem is the body of an error response. Code is stable, so clients should
+// match on it rather than on Detail.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Errors lists field-level validation failures
+	Errors []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// Errors renders the last error a handler attached with ctx.Error as an
+// application/problem+json response, with the status given by the error's kind.
+// Unclassified errors become a 500 whose details are left to the request log; a
+// cancelled request context becomes a 499, so a client hanging up is not counted
+// or logged as a server error.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		status := apperror.Status(err)
+		detail := err.Error()
+		if status >= http.StatusInternalServerError {
+			detail = "The server could not complete the request, try again later"
+		}
+
+		code := apperror.Code(err)
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(status, Problem{
+			Type:      problemTypePrefix + code,
+			Title:     apperror.StatusText(status),
+			Status:    status,
+			Detail:    detail,
+			Instance:  c.Request.URL.Path,
+			Code:      code,
+			RequestID: logging.RequestID(c.Request.Context()),
+			Errors:    apperror.Fields(err),
+		})
+	}
+}
+
+// NoRoute reports requests that match no route as a problem
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Error(ErrRouteNotFound)
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newErrorsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Errors())
+	r.Use(Recovery())
+	r.NoRoute(NoRoute())
+	r.GET("/conflict", func(c *gin.Context) {
+		c.Error(apperror.Conflict("duplicate_thing", "thing already exists"))
+	})
+	r.GET("/internal", func(c *gin.Context) {
+		c.Error(errors.New("connection reset by mongo-0.internal"))
+	})
+	r.GET("/canceled", func(c *gin.Context) {
+		c.Error(fmt.Errorf("find: %w", context.Canceled))
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.GET("/written", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.String(http.StatusAccepted, "accepted")
+	})
+	return r
+}
+
+func problemRequest(t *testing.T, r *gin.Engine, path string) (*httptest.ResponseRecorder, Problem) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var problem Problem
+	if w.Header().Get("Content-Type") == ProblemContentType {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	}
+	return w, problem
+}
+
+func TestErrors(t *testing.T) {
+	r := newErrorsRouter()
+
+	t.Run("classified error", func(t *testing.T) {
+		w, problem := problemRequest(t, r, "/conflict")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, Problem{
+			Type:     "urn:hypergro:problem:duplicate_thing",
+			Title:    "Conflict",
+			Status:   http.StatusConflict,
+			Detail:   "thing already exists",
+			Instance: "/conflict",
+			Code:     "duplicate_thing",
+		}, problem)
+	})
+
+	t.Run("internal errors are not leaked", func(t *testing.T) {
+		w, problem := problemRequest(t, r, "/internal")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", problem.Code)
+		assert.NotContains(t, problem.Detail, "mongo")
+	})
+
+	t.Run("client hung up", func(t *testing.T) {
+		w, problem := problemRequest(t, r, "/canceled")
+		assert.Equal(t, apperror.StatusClientClosedRequest, w.Code)
+		assert.Equal(t, "client_closed_request", problem.Code)
+		assert.Equal(t, "Client Closed Request", problem.Title)
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		w, problem := problemRequest(t, r, "/panic")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", problem.Code)
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		w, problem := problemRequest(t, r, "/nowhere")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "route_not_found", problem.Code)
+	})
+
+	t.Run("written responses are kept", func(t *testing.T) {
+		w, _ := problemRequest(t, r, "/written")
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "accepted", w.Body.String())
+	})
+}
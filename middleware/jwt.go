@@ -5,11 +5,10 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
-	"github.com/spf13/viper"
 )
 
 var (
-	jwtExpiration int
+	jwtExpiration time.Duration
 	signingKey    []byte
 )
 
@@ -18,16 +17,19 @@ type JWTClaims struct {
 	jwt.StandardClaims
 }
 
-func init() {
-	jwtExpiration = viper.GetInt("JWT.EXPIRE")
-	signingKey = []byte(viper.GetString("JWT.PRIVATE_KEY"))
+// ConfigureJWT sets the key tokens are signed with and how long they stay valid.
+// Call it once at startup, before serving requests.
+func ConfigureJWT(secret string, expiration time.Duration) {
+	signingKey = []byte(secret)
+	jwtExpiration = expiration
 }
+
 func GenerateToken(userId string) (string, error) {
 
 	claims := JWTClaims{
 		userId,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Duration(jwtExpiration) * time.Minute).Unix(),
+			ExpiresAt: time.Now().Add(jwtExpiration).Unix(),
 			IssuedAt:  jwt.TimeFunc().Unix(),
 			Issuer:    "Hypergro",
 		},
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"Praiseson6065/Hypergro-assign/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that hit no route, so scanners probing random
+// paths cannot create a series per path
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of every request by its route template
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		metrics.HTTPRequests.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route)
+	}
+}
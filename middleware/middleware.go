@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"net/http"
+	"Praiseson6065/Hypergro-assign/logging"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,27 +13,30 @@ func Authenicator() gin.HandlerFunc {
 
 		authorization := ctx.GetHeader("Authorization")
 		if len(authorization) == 0 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			abort(ctx, ErrMissingAuthorization)
 			return
 		}
 		fields := strings.Fields(authorization)
 		if len(fields) != 2 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is invalid"})
+			abort(ctx, ErrInvalidAuthorization)
 			return
 		}
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != "bearer" {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization type is invalid"})
+			abort(ctx, ErrInvalidAuthorization)
+			return
 		}
 
 		encodedToken := fields[1]
 		userId, err := ValidateToken(encodedToken)
 
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid Token"})
+			abort(ctx, ErrInvalidToken)
+			return
 		}
 
 		ctx.Set("userId", userId)
+		ctx.Request = ctx.Request.WithContext(logging.WithUserID(ctx.Request.Context(), userId))
 
 		ctx.Next()
 
@@ -41,6 +44,28 @@ func Authenicator() gin.HandlerFunc {
 
 }
 
+// OptionalAuthenticator sets the userId when a valid bearer token is supplied
+// but lets anonymous requests through
+func OptionalAuthenticator() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fields := strings.Fields(ctx.GetHeader("Authorization"))
+		if len(fields) == 2 && strings.ToLower(fields[0]) == "bearer" {
+			if userId, err := ValidateToken(fields[1]); err == nil {
+				ctx.Set("userId", userId)
+				ctx.Request = ctx.Request.WithContext(logging.WithUserID(ctx.Request.Context(), userId))
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
 func GetUserID(ctx *gin.Context) string {
 	return ctx.GetString("userId")
 }
+
+// abort stops the chain with an error for the Errors middleware to render
+func abort(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	ctx.Abort()
+}
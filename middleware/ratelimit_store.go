@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcra applies the generic cell rate algorithm: a token bucket that stores only the
+// theoretical arrival time (tat) of the next request. Requests are spaced window/limit
+// apart, and up to limit of them may arrive at once. Times are in nanoseconds.
+func gcra(now, tat int64, limit int, window time.Duration) (int64, RateLimitResult) {
+	interval := int64(window) / int64(limit)
+	if tat < now {
+		tat = now
+	}
+
+	newTAT := tat + interval
+	allowAt := newTAT - int64(window)
+	if now < allowAt {
+		return tat, RateLimitResult{
+			RetryAfter: time.Duration(allowAt - now),
+			Reset:      time.Duration(tat - now),
+		}
+	}
+
+	remaining := int((now - allowAt) / interval)
+	if remaining > limit-1 {
+		remaining = limit - 1
+	}
+	return newTAT, RateLimitResult{
+		Allowed:   true,
+		Remaining: remaining,
+		Reset:     time.Duration(newTAT - now),
+	}
+}
+
+// memorySweepEvery is how many requests pass between sweeps of expired buckets
+const memorySweepEvery = 1024
+
+// MemoryRateLimitStore counts requests in process. Each replica enforces the
+// limit on its own, so it suits tests and single-instance deployments.
+type MemoryRateLimitStore struct {
+	mu    sync.Mutex
+	tats  map[string]int64
+	calls int
+	now   func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{tats: make(map[string]int64), now: time.Now}
+}
+
+func (m *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UnixNano()
+	m.calls++
+	if m.calls%memorySweepEvery == 0 {
+		// A bucket whose tat has passed is full again and need not be kept
+		for k, tat := range m.tats {
+			if tat <= now {
+				delete(m.tats, k)
+			}
+		}
+	}
+
+	tat, result := gcra(now, m.tats[key], limit, window)
+	m.tats[key] = tat
+	return result, nil
+}
+
+// gcraScript is gcra run atomically in Redis, on the Redis clock so replicas with
+// skewed clocks still agree. Times are in microseconds. It returns allowed (0 or 1),
+// remaining, retry after and reset.
+var gcraScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local interval = window / limit
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - window
+if now < allow_at then
+  return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.min(limit - 1, math.floor((now - allow_at) / interval)), 0, new_tat - now}
+`)
+
+// RedisRateLimitStore counts requests in Redis, so a limit holds across every
+// replica. Each identity costs one key, which expires once its bucket is full again.
+// While Redis is unreachable each replica counts in memory, enforcing the limit
+// per replica rather than not at all.
+type RedisRateLimitStore struct {
+	client   *redis.Client
+	fallback *MemoryRateLimitStore
+	degraded atomic.Bool
+}
+
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, fallback: NewMemoryRateLimitStore()}
+}
+
+func (r *RedisRateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, r.client, []string{key}, limit, window.Microseconds()).Int64Slice()
+	if err != nil {
+		if ctx.Err() != nil {
+			return RateLimitResult{}, err
+		}
+		if !r.degraded.Swap(true) {
+			slog.WarnContext(ctx, "Rate limiting in memory while Redis is unreachable", "error", err)
+		}
+		return r.fallback.Allow(ctx, key, limit, window)
+	}
+	if r.degraded.Swap(false) {
+		slog.InfoContext(ctx, "Rate limiting back on Redis")
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Reset:      time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	allow := func(key string) RateLimitResult {
+		result, err := store.Allow(ctx, key, 3, time.Minute)
+		require.NoError(t, err)
+		return result
+	}
+
+	// A full bucket allows a burst of the whole limit
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := allow("a")
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result := allow("a")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.Reset)
+
+	// Other keys are counted separately
+	assert.True(t, allow("b").Allowed)
+
+	// One request's worth is replenished every window/limit
+	now = now.Add(20 * time.Second)
+	result = allow("a")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.False(t, allow("a").Allowed)
+
+	now = now.Add(time.Hour)
+	result = allow("a")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestCeilSeconds(t *testing.T) {
+	assert.Equal(t, 0, ceilSeconds(-time.Second))
+	assert.Equal(t, 1, ceilSeconds(time.Millisecond))
+	assert.Equal(t, 20, ceilSeconds(20*time.Second))
+}
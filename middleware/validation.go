@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"Praiseson6065/Hypergro-assign/apperror"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report fields by their JSON names, which are what clients send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+		if err := v.RegisterValidation("singleline", singleLine); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// singleLine rejects strings with line breaks or other control characters,
+// which must never reach values copied into headers such as email subjects
+func singleLine(fl validator.FieldLevel) bool {
+	return !strings.ContainsFunc(fl.Field().String(), unicode.IsControl)
+}
+
+// InvalidBody turns an error from binding or validating a request body into a
+// validation error that lists each failing field
+func InvalidBody(err error) error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]apperror.FieldError, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, apperror.FieldError{
+				Field:   fieldPath(fieldErr),
+				Message: validationMessage(fieldErr),
+			})
+		}
+		return apperror.InvalidFields("invalid_body", "The request body has invalid fields", fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apperror.InvalidFields("invalid_body", "The request body has invalid fields", []apperror.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be a " + jsonTypeName(typeErr.Type),
+		}})
+	}
+
+	return apperror.Validation("invalid_body", err.Error())
+}
+
+// jsonFieldName names a struct field as it appears in JSON
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+// fieldPath drops the struct name from the validator's namespace, so nested
+// fields read like "amenities[2]"
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldErr.Field()
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be at least " + param
+	case "lte":
+		return "must be at most " + param
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", param)
+		}
+		return fmt.Sprintf("must have at least %s items", param)
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", param)
+		}
+		return fmt.Sprintf("must have at most %s items", param)
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "hexcolor":
+		return "must be a hex colour such as #1a2b3c"
+	case "email":
+		return "must be an email address"
+	case "singleline":
+		return "must be a single line without control characters"
+	default:
+		return "failed the " + fieldErr.Tag() + " check"
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
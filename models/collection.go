@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultCollectionName is the shortlist that backs the plain favorites endpoints
+const DefaultCollectionName = "Favorites"
+
+const (
+	ReactionUp   = "up"
+	ReactionDown = "down"
+)
+
+// FavoriteCollection is a named shortlist of saved properties owned by a user.
+// Collaborators may add and remove items, comment and react; a share token
+// exposes a read-only view to anyone holding the link.
+type FavoriteCollection struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID   `bson:"userId" json:"userId"`
+	Name          string               `bson:"name" json:"name"`
+	IsDefault     bool                 `bson:"isDefault" json:"isDefault"`
+	Items         []FavoriteItem       `bson:"items" json:"items"`
+	Collaborators []primitive.ObjectID `bson:"collaborators,omitempty" json:"collaborators"`
+	ShareToken    string               `bson:"shareToken,omitempty" json:"shareToken,omitempty"`
+	CreatedAt     time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// FavoriteItem is a property saved into a collection with the owner's private note
+type FavoriteItem struct {
+	PropertyID primitive.ObjectID `bson:"propertyId" json:"propertyId"`
+	Note       string             `bson:"note" json:"note"`
+	SavedAt    time.Time          `bson:"savedAt" json:"savedAt"`
+	AddedBy    primitive.ObjectID `bson:"addedBy,omitempty" json:"addedBy"`
+	Comments   []ItemComment      `bson:"comments,omitempty" json:"comments"`
+	Reactions  []ItemReaction     `bson:"reactions,omitempty" json:"reactions"`
+}
+
+type ItemComment struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Text      string             `bson:"text" json:"text"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// ItemReaction is a member's thumbs up or down on a saved property; one per member
+type ItemReaction struct {
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Value     string             `bson:"value" json:"value"`
+	ReactedAt time.Time          `bson:"reactedAt" json:"reactedAt"`
+}
+
+// FavoriteItemDetail is a collection item resolved to its listing
+type FavoriteItemDetail struct {
+	Property   Property           `json:"property"`
+	Note       string             `json:"note,omitempty"`
+	SavedAt    time.Time          `json:"savedAt"`
+	AddedBy    primitive.ObjectID `json:"addedBy"`
+	Comments   []ItemComment      `json:"comments,omitempty"`
+	ThumbsUp   int                `json:"thumbsUp"`
+	ThumbsDown int                `json:"thumbsDown"`
+}
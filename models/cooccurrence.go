@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PropertyNeighbours holds the listings most often saved together with a property
+type PropertyNeighbours struct {
+	PropertyID primitive.ObjectID `bson:"_id" json:"propertyId"`
+	Neighbours []Neighbour        `bson:"neighbours" json:"neighbours"`
+	ComputedAt time.Time          `bson:"computedAt" json:"computedAt"`
+}
+
+type Neighbour struct {
+	PropertyID primitive.ObjectID `bson:"propertyId" json:"propertyId"`
+	Support    int                `bson:"support" json:"support"`
+}
+
+// AlsoSavedProperty is a neighbour resolved to its listing
+type AlsoSavedProperty struct {
+	Property Property `json:"property"`
+	Support  int      `json:"savedTogether"`
+}
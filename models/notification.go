@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationPriceDrop    = "price_drop"
+	NotificationAvailability = "availability"
+
+	ChannelInApp   = "inapp"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Notification is a message about a saved property, kept in the user's in-app inbox
+type Notification struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID     `bson:"userId" json:"userId"`
+	Type       string                 `bson:"type" json:"type"`
+	PropertyID primitive.ObjectID     `bson:"propertyId" json:"propertyId"`
+	Title      string                 `bson:"title" json:"title"`
+	Message    string                 `bson:"message" json:"message"`
+	Data       map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+	ReadAt     *time.Time             `bson:"readAt,omitempty" json:"readAt,omitempty"`
+}
+
+// NotificationPreferences controls which alerts a user receives and where
+type NotificationPreferences struct {
+	PriceDrop    bool     `bson:"priceDrop" json:"priceDrop"`
+	Availability bool     `bson:"availability" json:"availability"`
+	Channels     []string `bson:"channels" json:"channels"`
+	WebhookURL   string   `bson:"webhookUrl,omitempty" json:"webhookUrl,omitempty"`
+}
+
+// DefaultNotificationPreferences applies to users who never changed their settings
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		PriceDrop:    true,
+		Availability: true,
+		Channels:     []string{ChannelInApp},
+	}
+}
+
+// Wants reports whether the preferences opt in to a notification type
+func (p NotificationPreferences) Wants(notificationType string) bool {
+	switch notificationType {
+	case NotificationPriceDrop:
+		return p.PriceDrop
+	case NotificationAvailability:
+		return p.Availability
+	default:
+		return false
+	}
+}
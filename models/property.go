@@ -27,4 +27,204 @@ type Property struct {
 	ListingType   string             `bson:"listingType" json:"listingType"`
 	CreatedBy     primitive.ObjectID `bson:"createdBy" json:"createdBy"`
 	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	Version       int64              `bson:"version" json:"version"`
+
+	// Status is where the listing is in its lifecycle; only active listings are
+	// shown in public search
+	Status string `bson:"status" json:"status"`
+	// The time of the latest move into each status
+	PublishedAt  *time.Time `bson:"publishedAt,omitempty" json:"publishedAt,omitempty"`
+	UnderOfferAt *time.Time `bson:"underOfferAt,omitempty" json:"underOfferAt,omitempty"`
+	SoldAt       *time.Time `bson:"soldAt,omitempty" json:"soldAt,omitempty"`
+	RentedAt     *time.Time `bson:"rentedAt,omitempty" json:"rentedAt,omitempty"`
+	ArchivedAt   *time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	// RenewedAt is when an active listing was last published or renewed; listings
+	// not renewed within the expiry period are archived
+	RenewedAt *time.Time `bson:"renewedAt,omitempty" json:"renewedAt,omitempty"`
+}
+
+const (
+	PropertyDraft      = "draft"
+	PropertyActive     = "active"
+	PropertyUnderOffer = "under_offer"
+	PropertySold       = "sold"
+	PropertyRented     = "rented"
+	PropertyArchived   = "archived"
+)
+
+// propertyTransitions lists the statuses a listing may move to from each status.
+// Sold listings can only be archived; rented and archived ones can be listed again.
+var propertyTransitions = map[string][]string{
+	PropertyDraft:      {PropertyActive, PropertyArchived},
+	PropertyActive:     {PropertyUnderOffer, PropertySold, PropertyRented, PropertyArchived},
+	PropertyUnderOffer: {PropertyActive, PropertySold, PropertyRented, PropertyArchived},
+	PropertySold:       {PropertyArchived},
+	PropertyRented:     {PropertyActive, PropertyArchived},
+	PropertyArchived:   {PropertyActive},
+}
+
+// CanTransition reports whether a listing may move from one status to another
+func CanTransition(from string, to string) bool {
+	for _, status := range propertyTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleTo reports whether the user may see the listing. Drafts are not
+// published yet, so only their owner can.
+func (p Property) VisibleTo(userID string) bool {
+	return p.Status != PropertyDraft || p.CreatedBy.Hex() == userID
+}
+
+// StatusTimestampField is the BSON name of the field recording when a listing
+// last moved into status, or "" for statuses that are not timestamped
+func StatusTimestampField(status string) string {
+	switch status {
+	case PropertyActive:
+		return "publishedAt"
+	case PropertyUnderOffer:
+		return "underOfferAt"
+	case PropertySold:
+		return "soldAt"
+	case PropertyRented:
+		return "rentedAt"
+	case PropertyArchived:
+		return "archivedAt"
+	default:
+		return ""
+	}
+}
+
+// PropertyFilter narrows a property listing; nil and empty fields are ignored
+type PropertyFilter struct {
+	Type      string `json:"type,omitempty"`
+	City      string `json:"city,omitempty"`
+	State     string `json:"state,omitempty"`
+	MinPrice  *int64 `json:"minPrice,omitempty"`
+	MaxPrice  *int64 `json:"maxPrice,omitempty"`
+	Bedrooms  *int   `json:"bedrooms,omitempty"`
+	Bathrooms *int   `json:"bathrooms,omitempty"`
+	Furnished string `json:"furnished,omitempty"`
+	Status    string `json:"status,omitempty"`
+}
+
+// PropertyCreateRequest is the body of a property create. It holds only the fields
+// a lister may set; the ID, owner, verification, rating and timestamps are set
+// by the server.
+type PropertyCreateRequest struct {
+	Title         string    `json:"title" binding:"required,max=200,singleline"`
+	Type          string    `json:"type" binding:"required,oneof=Apartment Bungalow Penthouse Studio Villa"`
+	Price         int64     `json:"price" binding:"required,gt=0"`
+	State         string    `json:"state" binding:"required,max=100"`
+	City          string    `json:"city" binding:"required,max=100"`
+	AreaSqFt      int64     `json:"areaSqFt" binding:"gte=0"`
+	Bedrooms      int       `json:"bedrooms" binding:"gte=0,lte=50"`
+	Bathrooms     int       `json:"bathrooms" binding:"gte=0,lte=50"`
+	Amenities     []string  `json:"amenities" binding:"max=50,dive,required,max=50"`
+	Furnished     string    `json:"furnished" binding:"omitempty,oneof=Furnished Semi Unfurnished"`
+	AvailableFrom time.Time `json:"availableFrom"`
+	ListedBy      string    `json:"listedBy" binding:"omitempty,oneof=Agent Builder Owner"`
+	Tags          []string  `json:"tags" binding:"max=50,dive,required,max=50"`
+	ColorTheme    string    `json:"colorTheme" binding:"omitempty,hexcolor"`
+	ListingType   string    `json:"listingType" binding:"omitempty,oneof=rent sale"`
+	// Status may hold a listing back as a draft; it is published by default
+	Status string `json:"status" binding:"omitempty,oneof=draft active"`
+}
+
+// Property builds the property to store for a validated request
+func (r PropertyCreateRequest) Property(createdBy primitive.ObjectID, now time.Time) Property {
+	return Property{
+		Title:         r.Title,
+		Type:          r.Type,
+		Price:         r.Price,
+		State:         r.State,
+		City:          r.City,
+		AreaSqFt:      r.AreaSqFt,
+		Bedrooms:      r.Bedrooms,
+		Bathrooms:     r.Bathrooms,
+		Amenities:     r.Amenities,
+		Furnished:     r.Furnished,
+		AvailableFrom: r.AvailableFrom,
+		ListedBy:      r.ListedBy,
+		Tags:          r.Tags,
+		ColorTheme:    r.ColorTheme,
+		ListingType:   r.ListingType,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		Status:        r.Status,
+	}
+}
+
+// PropertyUpdateRequest is the body of a property update. Nil fields are left
+// unchanged; set fields follow the same rules as on create.
+type PropertyUpdateRequest struct {
+	Title         *string    `json:"title" binding:"omitempty,min=1,max=200,singleline"`
+	Type          *string    `json:"type" binding:"omitempty,oneof=Apartment Bungalow Penthouse Studio Villa"`
+	Price         *int64     `json:"price" binding:"omitempty,gt=0"`
+	State         *string    `json:"state" binding:"omitempty,min=1,max=100"`
+	City          *string    `json:"city" binding:"omitempty,min=1,max=100"`
+	AreaSqFt      *int64     `json:"areaSqFt" binding:"omitempty,gte=0"`
+	Bedrooms      *int       `json:"bedrooms" binding:"omitempty,gte=0,lte=50"`
+	Bathrooms     *int       `json:"bathrooms" binding:"omitempty,gte=0,lte=50"`
+	Amenities     []string   `json:"amenities" binding:"omitempty,max=50,dive,required,max=50"`
+	Furnished     *string    `json:"furnished" binding:"omitempty,oneof=Furnished Semi Unfurnished"`
+	AvailableFrom *time.Time `json:"availableFrom"`
+	ListedBy      *string    `json:"listedBy" binding:"omitempty,oneof=Agent Builder Owner"`
+	Tags          []string   `json:"tags" binding:"omitempty,max=50,dive,required,max=50"`
+	ColorTheme    *string    `json:"colorTheme" binding:"omitempty,hexcolor"`
+	ListingType   *string    `json:"listingType" binding:"omitempty,oneof=rent sale"`
+}
+
+// Updates returns the fields to $set, keyed by their BSON names
+func (r PropertyUpdateRequest) Updates() map[string]interface{} {
+	updates := make(map[string]interface{})
+	if r.Title != nil {
+		updates["title"] = *r.Title
+	}
+	if r.Type != nil {
+		updates["type"] = *r.Type
+	}
+	if r.Price != nil {
+		updates["price"] = *r.Price
+	}
+	if r.State != nil {
+		updates["state"] = *r.State
+	}
+	if r.City != nil {
+		updates["city"] = *r.City
+	}
+	if r.AreaSqFt != nil {
+		updates["areaSqFt"] = *r.AreaSqFt
+	}
+	if r.Bedrooms != nil {
+		updates["bedrooms"] = *r.Bedrooms
+	}
+	if r.Bathrooms != nil {
+		updates["bathrooms"] = *r.Bathrooms
+	}
+	if r.Amenities != nil {
+		updates["amenities"] = r.Amenities
+	}
+	if r.Furnished != nil {
+		updates["furnished"] = *r.Furnished
+	}
+	if r.AvailableFrom != nil {
+		updates["availableFrom"] = *r.AvailableFrom
+	}
+	if r.ListedBy != nil {
+		updates["listedBy"] = *r.ListedBy
+	}
+	if r.Tags != nil {
+		updates["tags"] = r.Tags
+	}
+	if r.ColorTheme != nil {
+		updates["colorTheme"] = *r.ColorTheme
+	}
+	if r.ListingType != nil {
+		updates["listingType"] = *r.ListingType
+	}
+	return updates
 }
@@ -11,3 +11,21 @@ type Recommendation struct {
 	RecommendedBy primitive.ObjectID `bson:"recommendedBy" json:"recommendedBy"`
 	RecommendedAt time.Time          `bson:"recommendedAt" json:"recommendedAt"`
 }
+
+// PreferenceProfile summarises what a user tends to favorite and view.
+// Counts are weighted, so a favorite counts for more than a view.
+type PreferenceProfile struct {
+	Cities    map[string]float64 `json:"cities"`
+	Types     map[string]float64 `json:"types"`
+	Amenities map[string]float64 `json:"amenities"`
+	MinPrice  int64              `json:"minPrice"`
+	MaxPrice  int64              `json:"maxPrice"`
+	Seeds     []Property         `json:"-"`
+}
+
+// PersonalisedRecommendation is a ranked listing suggested from a user's profile.
+type PersonalisedRecommendation struct {
+	Property Property `json:"property"`
+	Score    float64  `json:"score"`
+	Reason   string   `json:"reason"`
+}
@@ -6,12 +6,34 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Roles a user can hold; users created before roles existed have none and are regular users
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID                      primitive.ObjectID   `bson:"_id,omitempty"`
-	Name                    string               `bson:"name" json:"name"`
-	Email                   string               `bson:"email" json:"email"`
-	Password                string               `bson:"password" json:"password"`
-	CreatedAt               time.Time            `bson:"createdAt" json:"createdAt"`
-	Favorites               []primitive.ObjectID `bson:"favorites" json:"favorites"`
-	RecommendationsReceived []Recommendation     `bson:"recommendationsReceived" json:"recommendationsReceived"`
+	ID                      primitive.ObjectID       `bson:"_id,omitempty"`
+	Name                    string                   `bson:"name" json:"name"`
+	Email                   string                   `bson:"email" json:"email"`
+	Password                string                   `bson:"password" json:"password"`
+	Role                    string                   `bson:"role,omitempty" json:"role,omitempty"`
+	CreatedAt               time.Time                `bson:"createdAt" json:"createdAt"`
+	RecommendationsReceived []Recommendation         `bson:"recommendationsReceived" json:"recommendationsReceived"`
+	RecentlyViewed          []PropertyView           `bson:"recentlyViewed" json:"recentlyViewed"`
+	NotificationPreferences *NotificationPreferences `bson:"notificationPreferences,omitempty" json:"notificationPreferences,omitempty"`
+}
+
+// Preferences returns the user's notification settings, falling back to the defaults
+func (u User) Preferences() NotificationPreferences {
+	if u.NotificationPreferences == nil {
+		return DefaultNotificationPreferences()
+	}
+	return *u.NotificationPreferences
+}
+
+// PropertyView records a listing the user opened, newest last.
+type PropertyView struct {
+	PropertyID primitive.ObjectID `bson:"propertyId" json:"propertyId"`
+	ViewedAt   time.Time          `bson:"viewedAt" json:"viewedAt"`
 }
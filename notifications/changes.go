@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"fmt"
+)
+
+// DetectChanges compares a property before and after an update and describes the
+// changes that savers of the property should hear about
+func DetectChanges(before models.Property, after models.Property) []models.Notification {
+	var changes []models.Notification
+
+	if after.Price < before.Price {
+		changes = append(changes, models.Notification{
+			Type:       models.NotificationPriceDrop,
+			PropertyID: after.ID,
+			Title:      "Price drop: " + after.Title,
+			Message:    fmt.Sprintf("%s in %s dropped from %d to %d.", after.Title, after.City, before.Price, after.Price),
+			Data: map[string]interface{}{
+				"oldPrice": before.Price,
+				"newPrice": after.Price,
+			},
+		})
+	}
+
+	if !after.AvailableFrom.Equal(before.AvailableFrom) {
+		changes = append(changes, models.Notification{
+			Type:       models.NotificationAvailability,
+			PropertyID: after.ID,
+			Title:      "Availability update: " + after.Title,
+			Message:    fmt.Sprintf("%s in %s is now available from %s.", after.Title, after.City, after.AvailableFrom.Format("2006-01-02")),
+			Data: map[string]interface{}{
+				"oldAvailableFrom": before.AvailableFrom,
+				"newAvailableFrom": after.AvailableFrom,
+			},
+		})
+	}
+
+	return changes
+}
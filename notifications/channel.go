@@ -0,0 +1,30 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+)
+
+// Channel delivers a notification to a user over one medium
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, user models.User, notification models.Notification) error
+}
+
+// InboxChannel stores notifications for the in-app inbox
+type InboxChannel struct {
+	inbox database.NotificationRepository
+}
+
+func NewInboxChannel(inbox database.NotificationRepository) *InboxChannel {
+	return &InboxChannel{inbox: inbox}
+}
+
+func (c *InboxChannel) Name() string {
+	return models.ChannelInApp
+}
+
+func (c *InboxChannel) Send(ctx context.Context, user models.User, notification models.Notification) error {
+	return c.inbox.Create(ctx, &notification)
+}
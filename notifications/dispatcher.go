@@ -0,0 +1,133 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const deliveryTimeout = 30 * time.Second
+
+type propertyChange struct {
+	before models.Property
+	after  models.Property
+}
+
+// Dispatcher turns property updates into notifications and delivers them on
+// background workers, so the request that made the change never waits on delivery
+type Dispatcher struct {
+	favorites database.FavoriteRepository
+	channels  map[string]Channel
+	queue     chan propertyChange
+	workers   int
+	wg        sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// NewDispatcher creates a dispatcher that alerts the users who saved a changed property
+func NewDispatcher(favorites database.FavoriteRepository, queueSize int, workers int, channels ...Channel) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	byName := make(map[string]Channel, len(channels))
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+
+	return &Dispatcher{
+		favorites: favorites,
+		channels:  byName,
+		queue:     make(chan propertyChange, queueSize),
+		workers:   workers,
+	}
+}
+
+// Start launches the delivery workers
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for change := range d.queue {
+				d.process(change)
+			}
+		}()
+	}
+}
+
+// Stop stops accepting changes and waits for queued deliveries to finish. It
+// may be called more than once.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// PropertyUpdated is a database.PropertyUpdateHook. It only enqueues the change;
+// when the queue is full, or the dispatcher has stopped, the change is dropped
+// rather than blocking the request.
+func (d *Dispatcher) PropertyUpdated(before models.Property, after models.Property) {
+	if len(DetectChanges(before, after)) == 0 {
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return
+	}
+
+	select {
+	case d.queue <- propertyChange{before: before, after: after}:
+	default:
+		slog.Warn("Notification queue full, dropping alerts", "property", after.ID.Hex())
+	}
+}
+
+func (d *Dispatcher) process(change propertyChange) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	notifications := DetectChanges(change.before, change.after)
+
+	users, err := d.favorites.FindUsersWhoSaved(ctx, change.after.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding users to notify", "property", change.after.ID.Hex(), "error", err)
+		return
+	}
+
+	for _, user := range users {
+		preferences := user.Preferences()
+		for _, notification := range notifications {
+			if !preferences.Wants(notification.Type) {
+				continue
+			}
+
+			notification.UserID = user.ID
+			notification.CreatedAt = time.Now()
+			for _, name := range preferences.Channels {
+				channel, ok := d.channels[name]
+				if !ok {
+					continue
+				}
+				if err := channel.Send(ctx, user, notification); err != nil {
+					slog.ErrorContext(ctx, "Error sending notification", "type", notification.Type, "user", user.ID.Hex(), "channel", name, "error", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDispatcherDropsChangesAfterStop(t *testing.T) {
+	dispatcher := NewDispatcher(database.NewMemoryFavoriteRepository(database.NewMemoryStore()), 10, 1)
+	dispatcher.Start()
+	dispatcher.Stop()
+
+	// A request still running at shutdown can update a property after Stop
+	before := models.Property{ID: primitive.NewObjectID(), Title: "Flat", Price: 200}
+	after := before
+	after.Price = 150
+	assert.NotPanics(t, func() { dispatcher.PropertyUpdated(before, after) })
+	assert.NotPanics(t, dispatcher.Stop)
+}
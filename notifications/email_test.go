@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeHeader(t *testing.T) {
+	assert.Equal(t, "Price drop: Flat", encodeHeader("Price drop: Flat"))
+	assert.Equal(t, "Price drop: FlatBcc: all@example.com", encodeHeader("Price drop: Flat\r\nBcc: all@example.com"))
+	assert.Equal(t, "=?utf-8?q?Price_drop:_Caf=C3=A9?=", encodeHeader("Price drop: Café"))
+}
+
+func TestEmailSubjectCannotAddHeaders(t *testing.T) {
+	channel := NewEmailChannel(SMTPConfig{Host: "localhost", Port: 25, From: "alerts@example.com"})
+	var sent string
+	channel.send = func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = string(msg)
+		return nil
+	}
+
+	err := channel.Send(context.Background(), models.User{Email: "user@example.com"}, models.Notification{
+		Title:   "Price drop: Flat\r\nBcc: all@example.com\r\n\r\nInjected body",
+		Message: "Flat dropped.",
+	})
+	require.NoError(t, err)
+
+	headers, body, _ := strings.Cut(sent, "\r\n\r\n")
+	assert.NotContains(t, headers, "\r\nBcc:")
+	assert.Equal(t, "Flat dropped.\r\n", body)
+}
+
+func TestSendMailTimesOut(t *testing.T) {
+	// A server that accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	channel := NewEmailChannel(SMTPConfig{Host: host, Port: portNumber, From: "alerts@example.com", Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err = channel.Send(context.Background(), models.User{Email: "user@example.com"}, models.Notification{Title: "Hi"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
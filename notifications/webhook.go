@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed is returned for webhooks that resolve to an address on
+// the server's own network rather than the public internet
+var ErrAddressNotAllowed = errors.New("webhook address is not a public internet address")
+
+// blockedNetworks are the non-public ranges the net.IP methods do not cover
+var blockedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+// WebhookChannel posts notifications as JSON to the URL in the user's preferences
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel returns a channel whose client only connects to public
+// addresses. The address is checked after the host is resolved, for every
+// connection, so a name that later resolves to an internal address is refused
+// too. Redirects are not followed, since they could point anywhere.
+func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
+	dialer := &net.Dialer{Timeout: timeout, Control: publicAddressesOnly}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &WebhookChannel{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (c *WebhookChannel) Name() string {
+	return models.ChannelWebhook
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, user models.User, notification models.Notification) error {
+	webhookURL := user.Preferences().WebhookURL
+	if webhookURL == "" {
+		return errors.New("user has no webhook URL")
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Hypergro-Notifications")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// IsPublicIP reports whether ip is a public internet address, rather than a
+// loopback, private, link-local, unspecified or otherwise reserved one
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicAddressesOnly is a net.Dialer Control that refuses to connect to
+// addresses that are not public
+func publicAddressesOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+	}
+	return nil
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, address := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0", "100.64.0.1", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.False(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+	for _, address := range []string{"8.8.8.8", "93.184.216.34", "2606:4700::1111"} {
+		assert.True(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestWebhookRefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(time.Second)
+	user := models.User{NotificationPreferences: &models.NotificationPreferences{WebhookURL: server.URL}}
+	err := channel.Send(context.Background(), user, models.Notification{Title: "Hi"})
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	assert.False(t, called)
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"Praiseson6065/Hypergro-assign/config"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterFile, File: file, ServiceName: "test", SampleRatio: 1}, "test")
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "GET /api/properties/:id")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "GET /api/properties/:id")
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone}, "test")
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	// A traceparent set by nginx is picked up and passed on unchanged
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(incoming))
+
+	outgoing := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outgoing))
+	assert.Equal(t, incoming.Get("traceparent"), outgoing.Get("traceparent"))
+}
+
+func TestSetupRejectsBadConfig(t *testing.T) {
+	_, err := Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"}, "test")
+	assert.Error(t, err)
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: ExporterOTLP}, "test")
+	assert.Error(t, err)
+}
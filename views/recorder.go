@@ -0,0 +1,100 @@
+package views
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueueSize = 1000
+	defaultWorkers   = 2
+	// writeTimeout bounds each view write, as the request that made the view is gone
+	writeTimeout = 5 * time.Second
+)
+
+type propertyView struct {
+	userID     string
+	propertyID string
+}
+
+// Recorder records the listings signed-in users open on background workers, so
+// a property read never waits on the write or leaves a goroutine behind
+type Recorder struct {
+	users   database.UserRepository
+	queue   chan propertyView
+	workers int
+	wg      sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// NewRecorder creates a recorder that writes views to the users' history
+func NewRecorder(users database.UserRepository, queueSize int, workers int) *Recorder {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	return &Recorder{
+		users:   users,
+		queue:   make(chan propertyView, queueSize),
+		workers: workers,
+	}
+}
+
+// Start launches the workers
+func (r *Recorder) Start() {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for view := range r.queue {
+				r.process(view)
+			}
+		}()
+	}
+}
+
+// Stop stops accepting views and waits for the queued ones to be written. It
+// may be called more than once.
+func (r *Recorder) Stop() {
+	r.mu.Lock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+// Record enqueues a view. When the queue is full, or the recorder has stopped,
+// the view is dropped rather than blocking the request.
+func (r *Recorder) Record(userID string, propertyID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.stopped {
+		return
+	}
+
+	select {
+	case r.queue <- propertyView{userID: userID, propertyID: propertyID}:
+	default:
+		slog.Warn("View queue full, dropping view", "user", userID, "property", propertyID)
+	}
+}
+
+func (r *Recorder) process(view propertyView) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := r.users.RecordPropertyView(ctx, view.userID, view.propertyID); err != nil {
+		slog.ErrorContext(ctx, "Error recording property view", "user", view.userID, "property", view.propertyID, "error", err)
+	}
+}
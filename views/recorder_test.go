@@ -0,0 +1,33 @@
+package views
+
+import (
+	"Praiseson6065/Hypergro-assign/database"
+	"Praiseson6065/Hypergro-assign/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecorder(t *testing.T) {
+	users := database.NewMemoryUserRepository(database.NewMemoryStore())
+	userID, err := users.FirstOrCreate(context.Background(), &models.User{Email: "viewer@example.com"})
+	require.NoError(t, err)
+
+	recorder := NewRecorder(users, 10, 1)
+	recorder.Start()
+	recorder.Record(userID, primitive.NewObjectID().Hex())
+	recorder.Record(userID, primitive.NewObjectID().Hex())
+
+	// Stop writes the queued views before returning
+	recorder.Stop()
+	user, err := users.GetByID(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Len(t, user.RecentlyViewed, 2)
+
+	// Views arriving after shutdown are dropped rather than panicking
+	assert.NotPanics(t, func() { recorder.Record(userID, primitive.NewObjectID().Hex()) })
+	recorder.Stop()
+}